@@ -1,15 +1,25 @@
 package main
 
 import (
+	"MovieService/internal/pkg/middleware"
+	"MovieService/internal/pkg/policy"
+	"MovieService/internal/pkg/utils/hasher"
 	"MovieService/internal/pkg/utils/jwt"
+	"MovieService/internal/pkg/utils/logger"
+	"MovieService/internal/pkg/utils/oidc"
 	"context"
 	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"log/slog"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"MovieService/internal/pkg/auth"
 	authHandler "MovieService/internal/pkg/auth/http"
 	authRepo "MovieService/internal/pkg/auth/repo"
 	authUsecase "MovieService/internal/pkg/auth/usecase"
@@ -21,9 +31,13 @@ import (
 	moviesHandler "MovieService/internal/pkg/movies/http"
 	moviesRepo "MovieService/internal/pkg/movies/repo"
 	moviesUsecase "MovieService/internal/pkg/movies/usecase"
+
+	"MovieService/internal/pkg/audit"
+	auditHandler "MovieService/internal/pkg/audit/http"
+	auditRepo "MovieService/internal/pkg/audit/repo"
+	auditUsecase "MovieService/internal/pkg/audit/usecase"
 )
 
-// Логгер
 // Swagger
 
 // @title MovieService
@@ -33,6 +47,16 @@ import (
 // @host localhost:8080
 // @schemes http
 // @BasePath /
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access token as "Bearer <token>". Browsers may send it in the AccessToken cookie instead
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key of a machine client, created at /api/auth/api-keys
 func main() {
 	if err := run(); err != nil {
 		fmt.Println(err)
@@ -53,12 +77,26 @@ func run() (err error) {
 	dbName := os.Getenv("DB_NAME")
 	secretKey := os.Getenv("SECRET_KEY")
 
-	db, err := pgxpool.New(context.Background(), fmt.Sprintf("postgres://%v:%v@%v:%v/%v?sslmode=disable",
+	log, err := logger.New(os.Stdout, envString("LOG_FORMAT", logger.FormatText), envString("LOG_LEVEL", "info"))
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(log)
+
+	dbConfig, err := pgxpool.ParseConfig(fmt.Sprintf("postgres://%v:%v@%v:%v/%v?sslmode=disable",
 		dbUser,
 		dbPassword,
 		dbHost,
 		dbPort,
 		dbName))
+	if err != nil {
+		err = fmt.Errorf("error happened in pgxpool.ParseConfig: %w", err)
+
+		return err
+	}
+	dbConfig.ConnConfig.Tracer = logger.Tracer()
+
+	db, err := pgxpool.NewWithConfig(context.Background(), dbConfig)
 	if err != nil {
 		err = fmt.Errorf("error happened in sql.Open: %w", err)
 
@@ -72,34 +110,220 @@ func run() (err error) {
 		return err
 	}
 
-	err = jwt.LoadSecret(secretKey)
+	tokenManager, err := newTokenManager(secretKey)
 	if err != nil {
-		fmt.Println("загрузка секрета")
+		log.Error("failed to load signing keys", "error", err)
 		return err
 	}
 
-	log := &slog.Logger{}
+	accessPolicy := policy.Default()
+	if path := os.Getenv("POLICY_FILE"); path != "" {
+		accessPolicy, err = policy.Load(path)
+		if err != nil {
+			return fmt.Errorf("error happened in policy.Load: %w", err)
+		}
+	}
+
+	passwordHasher, err := hasher.NewArgon2Hasher(passwordHashParams())
+	if err != nil {
+		return fmt.Errorf("error happened in hasher.NewArgon2Hasher: %w", err)
+	}
+
+	var attemptStore auth.AttemptStore = authRepo.NewMemoryAttemptStore()
+	if os.Getenv("ATTEMPT_STORE") == "postgres" {
+		attemptStore = authRepo.NewPostgresAttemptStore(db)
+	}
+
+	authConfig := authUsecase.DefaultConfig
+	authConfig.RefreshTTL = envDuration("REFRESH_TOKEN_TTL", authConfig.RefreshTTL)
+	authConfig.PasswordPolicy = passwordPolicy()
+	authConfig.Throttle = throttleConfig()
+	authConfig.MFA.Issuer = envString("TOTP_ISSUER", authConfig.MFA.Issuer)
+
+	auditRepo := auditRepo.NewAuditRepo(db)
+	auditUsecase := auditUsecase.NewAuditUsecase(auditRepo)
 
 	authRepo := authRepo.NewAuthRepo(db)
-	authUsecase := authUsecase.NewAuthUsecase(authRepo)
-	authHandler := authHandler.NewAuthHandler(log, authUsecase)
+	authUsecase := authUsecase.NewAuthUsecase(authRepo, attemptStore, passwordHasher, tokenManager,
+		auditUsecase, authConfig)
+	migrated, err := authUsecase.MigrateLegacyPasswords(context.Background())
+	if err != nil {
+		return fmt.Errorf("error happened in MigrateLegacyPasswords: %w", err)
+	}
+	if migrated > 0 {
+		log.Info("hashed legacy plaintext passwords", "count", migrated)
+	}
+
+	authMiddleware := middleware.NewAuthMiddleware(tokenManager, authUsecase, accessPolicy)
+	keysHandler := authHandler.NewKeysHandler(tokenManager)
+	usersHandler := authHandler.NewUsersHandler(authUsecase)
+	secureCookies := os.Getenv("COOKIE_SECURE") != "false"
+	oidcHandler := authHandler.NewOIDCHandler(newOIDCUsecase(authRepo, authUsecase, auditUsecase),
+		os.Getenv("OIDC_POST_LOGIN_REDIRECT"), secureCookies)
+	authHandler := authHandler.NewAuthHandler(authUsecase, secureCookies)
+	auditHandler := auditHandler.NewAuditHandler(auditUsecase)
 
 	actorRepo := actorsRepo.NewActorsRepo(db)
-	actorUsecase := actorsUsecase.NewActorsUsecase(actorRepo)
-	actorHandler := actorsHandler.NewActorsHandler(log, actorUsecase)
+	actorUsecase := actorsUsecase.NewActorsUsecase(actorRepo, auditUsecase)
+	actorHandler := actorsHandler.NewActorsHandler(actorUsecase)
 
 	movieRepo := moviesRepo.NewMoviesRepo(db)
-	movieUsecase := moviesUsecase.NewMoviesUsecase(movieRepo)
-	movieHandler := moviesHandler.NewMoviesHandler(log, movieUsecase)
+	movieUsecase := moviesUsecase.NewMoviesUsecase(movieRepo, auditUsecase)
+	movieHandler := moviesHandler.NewMoviesHandler(movieUsecase)
+
+	router := routes(authMiddleware, handlers{
+		auth:   &authHandler,
+		oidc:   &oidcHandler,
+		keys:   &keysHandler,
+		users:  &usersHandler,
+		movies: &movieHandler,
+		actors: &actorHandler,
+		audit:  &auditHandler,
+	})
+
+	handler := middleware.Chain(router,
+		middleware.RequestID(log),
+		middleware.AccessLog,
+		middleware.Recover,
+		audit.WithRequestInfo,
+	)
+
+	log.Info("listening", "addr", ":8080")
+	return http.ListenAndServe(":8080", handler)
+}
+
+// newTokenManager builds the keyring from SECRET_KEY (an HS256 secret with
+// the SECRET_KEY_ID kid) and the keys of JWT_KEYS_DIR. Tokens are signed with
+// JWT_SIGNING_KEY_ID, the SECRET_KEY one by default.
+func newTokenManager(secretKey string) (*jwt.Manager, error) {
+	cfg := jwt.Config{
+		SigningKeyId: os.Getenv("JWT_SIGNING_KEY_ID"),
+		Issuer:       envString("JWT_ISSUER", jwt.DefaultIssuer),
+		Audience:     envString("JWT_AUDIENCE", jwt.DefaultAudience),
+		AccessTTL:    envDuration("ACCESS_TOKEN_TTL", jwt.DefaultAccessTTL),
+	}
+
+	if secretKey != "" {
+		key, err := jwt.NewHMACKey(envString("SECRET_KEY_ID", "default"), []byte(secretKey))
+		if err != nil {
+			return nil, err
+		}
+		cfg.Keys = append(cfg.Keys, key)
+
+		if cfg.SigningKeyId == "" {
+			cfg.SigningKeyId = key.Id
+		}
+	}
+
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		keys, err := jwt.LoadKeyDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("error happened in jwt.LoadKeyDir: %w", err)
+		}
+		cfg.Keys = append(cfg.Keys, keys...)
+	}
 
-	mux := http.NewServeMux()
+	return jwt.NewManager(cfg)
+}
+
+// newOIDCUsecase enables single sign-on when OIDC_ISSUER is set. The issuer
+// is contacted on the first login, not at startup.
+func newOIDCUsecase(repo auth.AuthRepo, sessions auth.AuthUsecase, recorder audit.Recorder) auth.OIDCUsecase {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil
+	}
+
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       issuer,
+		ClientId:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       strings.Fields(os.Getenv("OIDC_SCOPES")),
+		GroupsClaim:  os.Getenv("OIDC_GROUPS_CLAIM"),
+	}, nil)
+
+	return authUsecase.NewOIDCUsecase(repo, provider, sessions, recorder, authUsecase.OIDCConfig{
+		AdminGroup: os.Getenv("OIDC_ADMIN_GROUP"),
+	})
+}
+
+// passwordHashParams reads the argon2id cost from PASSWORD_HASH_MEMORY (KiB),
+// PASSWORD_HASH_TIME and PASSWORD_HASH_THREADS. Values out of range are
+// clamped to zero so that NewArgon2Hasher rejects them instead of wrapping.
+func passwordHashParams() hasher.Params {
+	params := hasher.DefaultParams
+	params.Memory = uint32(envRange("PASSWORD_HASH_MEMORY", int(params.Memory), math.MaxUint32))
+	params.Time = uint32(envRange("PASSWORD_HASH_TIME", int(params.Time), math.MaxUint32))
+	params.Threads = uint8(envRange("PASSWORD_HASH_THREADS", int(params.Threads), math.MaxUint8))
+
+	return params
+}
 
-	mux.Handle("/api/actors/", &actorHandler)
-	mux.Handle("/api/movies/", &movieHandler)
-	mux.Handle("/api/auth/", &authHandler)
-	mux.Handle("/api/actors", &actorHandler)
-	mux.Handle("/api/movies", &movieHandler)
-	mux.Handle("/api/auth", &authHandler)
+func envRange(key string, def int, max int) int {
+	v := envInt(key, def)
+	if v < 0 || v > max {
+		return 0
+	}
+
+	return v
+}
+
+func envString(key string, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+
+	return def
+}
+
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+
+	return v
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+
+	return v
+}
+
+func passwordPolicy() authUsecase.PasswordPolicy {
+	policy := authUsecase.DefaultPasswordPolicy
+	policy.MinLength = envInt("PASSWORD_MIN_LENGTH", policy.MinLength)
+	policy.MaxLength = envInt("PASSWORD_MAX_LENGTH", policy.MaxLength)
+	policy.RequireUpper = envBool("PASSWORD_REQUIRE_UPPER", policy.RequireUpper)
+	policy.RequireLower = envBool("PASSWORD_REQUIRE_LOWER", policy.RequireLower)
+	policy.RequireDigit = envBool("PASSWORD_REQUIRE_DIGIT", policy.RequireDigit)
+	policy.RequireSymbol = envBool("PASSWORD_REQUIRE_SYMBOL", policy.RequireSymbol)
+
+	return policy
+}
+
+func throttleConfig() authUsecase.ThrottleConfig {
+	throttle := authUsecase.DefaultThrottleConfig
+	throttle.LoginThreshold = envInt("LOGIN_LOCKOUT_THRESHOLD", throttle.LoginThreshold)
+	throttle.IPThreshold = envInt("IP_LOCKOUT_THRESHOLD", throttle.IPThreshold)
+	throttle.LockoutDuration = envDuration("LOGIN_LOCKOUT_DURATION", throttle.LockoutDuration)
+	throttle.BaseDelay = envDuration("LOGIN_BACKOFF_BASE", throttle.BaseDelay)
+	throttle.MaxDelay = envDuration("LOGIN_BACKOFF_MAX", throttle.MaxDelay)
+	throttle.Window = envDuration("LOGIN_ATTEMPT_WINDOW", throttle.Window)
+
+	return throttle
+}
+
+func envBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
 
-	return http.ListenAndServe(":8080", mux)
+	return v
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"MovieService/internal/pkg/middleware"
+	"MovieService/internal/pkg/policy"
+	"MovieService/internal/pkg/utils/router"
+
+	actorsHandler "MovieService/internal/pkg/actors/http"
+	auditHandler "MovieService/internal/pkg/audit/http"
+	authHandler "MovieService/internal/pkg/auth/http"
+	moviesHandler "MovieService/internal/pkg/movies/http"
+)
+
+type handlers struct {
+	auth   *authHandler.AuthHandler
+	oidc   *authHandler.OIDCHandler
+	keys   *authHandler.KeysHandler
+	users  *authHandler.UsersHandler
+	movies *moviesHandler.MoviesHandler
+	actors *actorsHandler.ActorsHandler
+	audit  *auditHandler.AuditHandler
+}
+
+// routes is the route table of the API, one line per endpoint.
+func routes(mw *middleware.AuthMiddleware, h handlers) *router.Router {
+	rt := router.New()
+	auth, perm := mw.RequireAuth, mw.RequirePermission
+
+	rt.HandleFunc("GET /.well-known/jwks.json", h.keys.GetKeys)
+
+	rt.HandleFunc("POST /api/auth/signIn", h.auth.SignIn)
+	rt.HandleFunc("POST /api/auth/signUp", h.auth.SignUp)
+	rt.HandleFunc("POST /api/auth/refresh", h.auth.Refresh)
+	rt.HandleFunc("POST /api/auth/logout", auth(h.auth.Logout))
+	rt.HandleFunc("POST /api/auth/logout-all", auth(h.auth.LogoutAll))
+	rt.HandleFunc("POST /api/auth/password", auth(h.auth.ChangePassword))
+	rt.HandleFunc("POST /api/auth/password/reset", h.auth.ResetPassword)
+	rt.HandleFunc("GET /api/auth/me", auth(h.auth.GetMe))
+	rt.HandleFunc("DELETE /api/auth/me", auth(h.auth.DeleteMe))
+	rt.HandleFunc("POST /api/auth/2fa/verify", h.auth.VerifyMFA)
+	rt.HandleFunc("POST /api/auth/2fa/enroll", auth(h.auth.EnrollTOTP))
+	rt.HandleFunc("POST /api/auth/2fa/confirm", auth(h.auth.ConfirmTOTP))
+	rt.HandleFunc("POST /api/auth/2fa/recovery-codes", auth(h.auth.RegenerateRecoveryCodes))
+	rt.HandleFunc("DELETE /api/auth/2fa", auth(h.auth.DisableTOTP))
+	rt.HandleFunc("GET /api/auth/api-keys", auth(h.auth.GetApiKeys))
+	rt.HandleFunc("POST /api/auth/api-keys", mw.RequireMFA(h.auth.CreateApiKey))
+	rt.HandleFunc("DELETE /api/auth/api-keys/{id:int}", auth(h.auth.RevokeApiKey))
+
+	rt.HandleFunc("GET /api/auth/oidc/login", h.oidc.Login)
+	rt.HandleFunc("POST /api/auth/oidc/link", auth(h.oidc.Link))
+	rt.HandleFunc("GET /api/auth/oidc/callback", h.oidc.Callback)
+
+	rt.HandleFunc("GET /api/users", perm(policy.UsersRead, h.users.GetUsers))
+	rt.HandleFunc("GET /api/users/{id:int}", perm(policy.UsersRead, h.users.GetUser))
+	rt.HandleFunc("DELETE /api/users/{id:int}", perm(policy.UsersDelete, h.users.DeleteUser))
+	rt.HandleFunc("PUT /api/users/{id:int}/admin", perm(policy.UsersWrite, h.users.GrantAdmin))
+	rt.HandleFunc("DELETE /api/users/{id:int}/admin", perm(policy.UsersWrite, h.users.RevokeAdmin))
+	rt.HandleFunc("PUT /api/users/{id:int}/disabled", perm(policy.UsersWrite, h.users.DisableUser))
+	rt.HandleFunc("DELETE /api/users/{id:int}/disabled", perm(policy.UsersWrite, h.users.EnableUser))
+	rt.HandleFunc("DELETE /api/users/{id:int}/2fa", perm(policy.UsersWrite, h.users.ResetTOTP))
+	rt.HandleFunc("POST /api/users/{id:int}/password-reset", perm(policy.UsersWrite, h.users.ForcePasswordReset))
+	rt.HandleFunc("GET /api/users/lockouts", perm(policy.UsersRead, h.users.GetLockouts))
+	rt.HandleFunc("DELETE /api/users/lockouts/{key}", perm(policy.UsersWrite, h.users.Unlock))
+	rt.HandleFunc("DELETE /api/users/api-keys/stale", perm(policy.UsersWrite, h.users.PruneApiKeys))
+
+	rt.HandleFunc("GET /api/movies", perm(policy.MoviesRead, h.movies.GetMovies))
+	rt.HandleFunc("GET /api/movies/search", perm(policy.MoviesRead, h.movies.GetMoviesBySearch))
+	rt.HandleFunc("GET /api/movies/{id:int}", perm(policy.MoviesRead, h.movies.GetMovie))
+	rt.HandleFunc("POST /api/movies", perm(policy.MoviesWrite, h.movies.AddMovie))
+	rt.HandleFunc("PUT /api/movies/{id:int}", perm(policy.MoviesWrite, h.movies.UpdateMovie))
+	rt.HandleFunc("PATCH /api/movies/{id:int}", perm(policy.MoviesWrite, h.movies.PatchMovie))
+	rt.HandleFunc("DELETE /api/movies/{id:int}", perm(policy.MoviesDelete, h.movies.DeleteMovie))
+	rt.HandleFunc("POST /api/movies/{id:int}/actors", perm(policy.MoviesWrite, h.movies.AddActorToMovie))
+	rt.HandleFunc("DELETE /api/movies/{movieId:int}/actors/{actorId:int}", perm(policy.MoviesWrite, h.movies.DeleteActorFromMovie))
+
+	rt.HandleFunc("GET /api/actors", perm(policy.ActorsRead, h.actors.GetActors))
+	rt.HandleFunc("GET /api/actors/{id:int}", perm(policy.ActorsRead, h.actors.GetActor))
+	rt.HandleFunc("POST /api/actors", perm(policy.ActorsWrite, h.actors.AddActor))
+	rt.HandleFunc("PUT /api/actors/{id:int}", perm(policy.ActorsWrite, h.actors.UpdateActor))
+	rt.HandleFunc("PATCH /api/actors/{id:int}", perm(policy.ActorsWrite, h.actors.PatchActor))
+	rt.HandleFunc("DELETE /api/actors/{id:int}", perm(policy.ActorsDelete, h.actors.DeleteActor))
+
+	rt.HandleFunc("GET /api/audit", perm(policy.AuditRead, h.audit.GetEvents))
+	rt.HandleFunc("GET /api/audit/export", perm(policy.AuditRead, h.audit.ExportEvents))
+
+	return rt
+}
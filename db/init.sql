@@ -1,3 +1,7 @@
+-- The script can be run again on an existing database. Columns added to a
+-- table after it was first created are added by ALTER TABLE below it, so that
+-- running the script brings a database created by an older version up to date.
+
 CREATE TABLE IF NOT EXISTS "user"
 (
     id serial NOT NULL PRIMARY KEY,
@@ -6,6 +10,10 @@ CREATE TABLE IF NOT EXISTS "user"
     is_admin boolean DEFAULT false
 );
 
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS disabled boolean NOT NULL DEFAULT false;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS reset_token_hash text UNIQUE;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS reset_expires_at timestamptz;
+
 CREATE TABLE IF NOT EXISTS movie
 (
     id serial NOT NULL PRIMARY KEY,
@@ -16,6 +24,16 @@ CREATE TABLE IF NOT EXISTS movie
     CHECK (rating >= 0 AND rating <= 10)
 );
 
+ALTER TABLE movie ADD COLUMN IF NOT EXISTS version int NOT NULL DEFAULT 1;
+-- the russian configuration stems Cyrillic words as Russian and Latin ones as
+-- English, which covers the bilingual catalog
+ALTER TABLE movie ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian'::regconfig, name), 'A') ||
+    setweight(to_tsvector('russian'::regconfig, coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS movie_search_idx ON movie USING gin (search);
+
 CREATE TABLE IF NOT EXISTS actor
 (
     id serial NOT NULL PRIMARY KEY,
@@ -26,6 +44,8 @@ CREATE TABLE IF NOT EXISTS actor
     CHECK ( gender in ('F', 'M') )
 );
 
+ALTER TABLE actor ADD COLUMN IF NOT EXISTS version int NOT NULL DEFAULT 1;
+
 CREATE TABLE IF NOT EXISTS movie_actor
 (
     id serial NOT NULL PRIMARY KEY,
@@ -34,3 +54,143 @@ CREATE TABLE IF NOT EXISTS movie_actor
     FOREIGN KEY (movie_id) REFERENCES movie(id) ON DELETE CASCADE,
     FOREIGN KEY (actor_id) REFERENCES actor(id) ON DELETE CASCADE
 );
+
+-- an actor is in the cast of a movie once, older databases may have the same
+-- pair several times
+DELETE FROM movie_actor AS a USING movie_actor AS b
+    WHERE a.movie_id = b.movie_id AND a.actor_id = b.actor_id AND a.id > b.id;
+CREATE UNIQUE INDEX IF NOT EXISTS movie_actor_movie_id_actor_id_key ON movie_actor (movie_id, actor_id);
+
+CREATE TABLE IF NOT EXISTS session
+(
+    id serial NOT NULL PRIMARY KEY,
+    user_id int NOT NULL,
+    device text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    revoked_at timestamptz,
+    FOREIGN KEY (user_id) REFERENCES "user"(id) ON DELETE CASCADE
+);
+
+ALTER TABLE session ADD COLUMN IF NOT EXISTS access_jti text;
+ALTER TABLE session ADD COLUMN IF NOT EXISTS access_expires_at timestamptz;
+ALTER TABLE session ADD COLUMN IF NOT EXISTS mfa boolean NOT NULL DEFAULT false;
+-- sessions from before the column count as authenticated long ago
+ALTER TABLE session ADD COLUMN IF NOT EXISTS auth_time timestamptz NOT NULL DEFAULT 'epoch';
+
+CREATE TABLE IF NOT EXISTS refresh_token
+(
+    id serial NOT NULL PRIMARY KEY,
+    session_id int NOT NULL,
+    token_hash text NOT NULL UNIQUE,
+    expires_at timestamptz NOT NULL,
+    used_at timestamptz,
+    FOREIGN KEY (session_id) REFERENCES session(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS revoked_token
+(
+    jti text NOT NULL PRIMARY KEY,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS login_attempt
+(
+    key text NOT NULL PRIMARY KEY,
+    failures int NOT NULL DEFAULT 0,
+    last_failure timestamptz NOT NULL DEFAULT now(),
+    locked_until timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS api_key
+(
+    id serial NOT NULL PRIMARY KEY,
+    user_id int NOT NULL,
+    name text NOT NULL,
+    prefix text NOT NULL UNIQUE,
+    key_hash text NOT NULL UNIQUE,
+    permissions text[] NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz,
+    last_used_at timestamptz,
+    revoked_at timestamptz,
+    FOREIGN KEY (user_id) REFERENCES "user"(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_identity
+(
+    id serial NOT NULL PRIMARY KEY,
+    user_id int NOT NULL,
+    issuer text NOT NULL,
+    subject text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES "user"(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS oidc_state
+(
+    state_hash text NOT NULL PRIMARY KEY,
+    nonce text NOT NULL,
+    verifier text NOT NULL,
+    user_id int,
+    expires_at timestamptz NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES "user"(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_totp
+(
+    user_id int NOT NULL PRIMARY KEY,
+    secret text NOT NULL,
+    enabled_at timestamptz,
+    last_step bigint NOT NULL DEFAULT 0,
+    FOREIGN KEY (user_id) REFERENCES "user"(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS recovery_code
+(
+    id serial NOT NULL PRIMARY KEY,
+    user_id int NOT NULL,
+    code_hash text NOT NULL,
+    used_at timestamptz,
+    UNIQUE (user_id, code_hash),
+    FOREIGN KEY (user_id) REFERENCES "user"(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS mfa_challenge
+(
+    token_hash text NOT NULL PRIMARY KEY,
+    user_id int NOT NULL,
+    attempts int NOT NULL DEFAULT 0,
+    expires_at timestamptz NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES "user"(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id bigserial NOT NULL PRIMARY KEY,
+    time timestamptz NOT NULL DEFAULT now(),
+    actor_id int,
+    action text NOT NULL,
+    entity text NOT NULL,
+    entity_id text,
+    before jsonb,
+    after jsonb,
+    ip text,
+    user_agent text,
+    request_id text
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_id, time);
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity, entity_id, time);
+CREATE INDEX IF NOT EXISTS audit_log_time_idx ON audit_log (time);
+
+-- the audit log is append-only, rows are never changed or removed
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
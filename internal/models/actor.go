@@ -1,16 +1,30 @@
 package models
 
 import (
+	"MovieService/internal/pkg/utils/mergepatch"
+
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Actor struct {
 	Id        int                 `json:"id"`
-	Name      string              `json:"name"`
-	Surname   string              `json:"surname"`
-	Gender    string              `json:"gender"`
-	BirthDate pgtype.Date         `json:"birthDate"`
+	Name      string              `json:"name" validate:"required,max=16"`
+	Surname   string              `json:"surname" validate:"required,max=16"`
+	Gender    string              `json:"gender" validate:"required,oneof=F M"`
+	BirthDate pgtype.Date         `json:"birthDate" validate:"required"`
 	Movies    []MovieInActorSlice `json:"movies"`
+	// Version is bumped by every update, a change made in between fails
+	Version int `json:"version"`
+}
+
+// ActorPage is a page of the actor listing. Next is the cursor of the
+// following page, empty on the last one.
+type ActorPage struct {
+	Actors []Actor `json:"actors"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+	Total  *int    `json:"total,omitempty"`
+	Next   string  `json:"next,omitempty"`
 }
 
 type ActorInMovieSlice struct {
@@ -20,3 +34,19 @@ type ActorInMovieSlice struct {
 	Gender    string      `json:"gender"`
 	BirthDate pgtype.Date `json:"birthDate"`
 }
+
+// ActorPatch is a JSON merge patch of an actor. The filmography is changed
+// through the cast of the movies.
+type ActorPatch struct {
+	Name      mergepatch.Field[string]      `json:"name"`
+	Surname   mergepatch.Field[string]      `json:"surname"`
+	Gender    mergepatch.Field[string]      `json:"gender"`
+	BirthDate mergepatch.Field[pgtype.Date] `json:"birthDate"`
+}
+
+func (p *ActorPatch) Apply(a *Actor) {
+	p.Name.Apply(&a.Name)
+	p.Surname.Apply(&a.Surname)
+	p.Gender.Apply(&a.Gender)
+	p.BirthDate.Apply(&a.BirthDate)
+}
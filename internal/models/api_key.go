@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ApiKey is a long-lived credential of a machine client. Only the hash of
+// the key is stored, Prefix is kept to tell the keys apart in listings.
+type ApiKey struct {
+	Id          int        `json:"id"`
+	UserId      int        `json:"userId"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Permissions []string   `json:"permissions"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+}
+
+// NewApiKey is the key as it is returned once, on creation.
+type NewApiKey struct {
+	ApiKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// LoginAttempts is the failed sign-in counter of a login ("login:<login>") or
+// a client address ("ip:<address>").
+type LoginAttempts struct {
+	Key         string     `json:"key"`
+	Failures    int        `json:"failures"`
+	LastFailure time.Time  `json:"lastFailure"`
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"`
+}
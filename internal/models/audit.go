@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent is an entry of the append-only security log. ActorId is empty
+// for anonymous actions such as a failed sign-in.
+type AuditEvent struct {
+	Id        int64           `json:"id"`
+	Time      time.Time       `json:"time"`
+	ActorId   *int            `json:"actorId,omitempty"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityId  string          `json:"entityId,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	IP        string          `json:"ip,omitempty"`
+	UserAgent string          `json:"userAgent,omitempty"`
+	RequestId string          `json:"requestId,omitempty"`
+}
+
+type AuditFilter struct {
+	UserId   int
+	Action   string
+	Entity   string
+	EntityId string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+type AuditPage struct {
+	Events []AuditEvent `json:"events"`
+	Total  int          `json:"total"`
+	Page   int          `json:"page"`
+	Limit  int          `json:"limit"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ExternalIdentity is the user as an OpenID Connect issuer knows them.
+type ExternalIdentity struct {
+	Issuer   string
+	Subject  string
+	Username string
+	Email    string
+	Groups   []string
+	// AuthTime is when the user last logged in at the issuer, zero when the
+	// issuer did not tell.
+	AuthTime time.Time
+}
+
+// OIDCState is a pending authorization request. UserId is set when the
+// identity is to be linked to an existing account.
+type OIDCState struct {
+	Nonce    string
+	Verifier string
+	UserId   int
+}
+
+type OIDCLogin struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+}
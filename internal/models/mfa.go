@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// TOTP is the authenticator of a user. It is pending until the first code is
+// confirmed; LastStep is the last accepted time step, codes are never
+// accepted twice.
+type TOTP struct {
+	UserId    int
+	Secret    string
+	EnabledAt *time.Time
+	LastStep  int64
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"recoveryCodes"`
+}
+
+// MFAChallenge is returned by sign-in instead of tokens when the account has
+// two-factor authentication enabled.
+type MFAChallenge struct {
+	MfaRequired    bool      `json:"mfaRequired"`
+	ChallengeToken string    `json:"challengeToken"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+// MFAVerification completes a challenge with either an authenticator code
+// or one of the recovery codes.
+type MFAVerification struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code,omitempty"`
+	RecoveryCode   string `json:"recoveryCode,omitempty"`
+}
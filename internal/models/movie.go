@@ -1,14 +1,64 @@
 package models
 
-import "github.com/jackc/pgx/v5/pgtype"
+import (
+	"MovieService/internal/pkg/utils/mergepatch"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
 
 type Movie struct {
 	Id          int                 `json:"id"`
-	Name        string              `json:"name"`
-	Description string              `json:"description"`
-	ReleaseDate pgtype.Date         `json:"releaseDate"`
-	Rating      int                 `json:"rating"`
+	Name        string              `json:"name" validate:"required,max=150"`
+	Description string              `json:"description" validate:"max=1000"`
+	ReleaseDate pgtype.Date         `json:"releaseDate" validate:"required"`
+	Rating      int                 `json:"rating" validate:"min=0,max=10"`
 	Actors      []ActorInMovieSlice `json:"actors"`
+	// Version is bumped by every update, a change made in between fails
+	Version int `json:"version"`
+}
+
+// MoviePage is a page of a movie listing. Next is the cursor of the following
+// page, empty on the last one.
+type MoviePage struct {
+	Movies []Movie `json:"movies"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+	Total  *int    `json:"total,omitempty"`
+	Next   string  `json:"next,omitempty"`
+}
+
+// MovieHit is a movie found by the full-text search. The better the movie
+// matches, the higher its rank. The highlights are HTML, the text escaped and
+// the matched words wrapped in <b> tags, the snippet being the best fragments
+// of the description.
+type MovieHit struct {
+	Movie
+	Rank          float32 `json:"rank"`
+	NameHighlight string  `json:"nameHighlight"`
+	Snippet       string  `json:"snippet"`
+}
+
+// MovieSearchPage is a page of the hits of a full-text search, the best first.
+type MovieSearchPage struct {
+	Movies []MovieHit `json:"movies"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+	Total  *int       `json:"total,omitempty"`
+	Next   string     `json:"next,omitempty"`
+}
+
+// MovieFilter narrows a movie listing, every condition that is set must hold.
+// The release dates are inclusive.
+type MovieFilter struct {
+	MinRating           *int
+	MaxRating           *int
+	ReleasedFrom        *time.Time
+	ReleasedTo          *time.Time
+	WithActor           *int
+	WithoutActor        *int
+	NamePrefix          string
+	DescriptionContains string
 }
 
 type MovieInActorSlice struct {
@@ -18,3 +68,19 @@ type MovieInActorSlice struct {
 	ReleaseDate pgtype.Date `json:"releaseDate"`
 	Rating      int         `json:"rating"`
 }
+
+// MoviePatch is a JSON merge patch of a movie. The cast is changed through
+// its own endpoints.
+type MoviePatch struct {
+	Name        mergepatch.Field[string]      `json:"name"`
+	Description mergepatch.Field[string]      `json:"description"`
+	ReleaseDate mergepatch.Field[pgtype.Date] `json:"releaseDate"`
+	Rating      mergepatch.Field[int]         `json:"rating"`
+}
+
+func (p *MoviePatch) Apply(m *Movie) {
+	p.Name.Apply(&m.Name)
+	p.Description.Apply(&m.Description)
+	p.ReleaseDate.Apply(&m.ReleaseDate)
+	p.Rating.Apply(&m.Rating)
+}
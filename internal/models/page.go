@@ -0,0 +1,17 @@
+package models
+
+// PageRequest asks for Limit rows of a listing, skipping Offset rows or, when
+// Cursor is set, starting after the row the cursor points at. Total is
+// counted only when Count is set, it costs a scan of the whole listing.
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor string
+	Count  bool
+}
+
+// SortKey is a field of the sort order of a listing, named as in the API.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type Session struct {
+	Id        int        `json:"id"`
+	UserId    int        `json:"userId"`
+	Device    string     `json:"device"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	// Mfa is set when the session was opened with a second factor
+	Mfa bool `json:"mfa"`
+	// AuthTime is when the user proved who they are: the sign-in itself, or
+	// the login at the issuer for SSO.
+	AuthTime time.Time `json:"authTime"`
+}
+
+type RefreshToken struct {
+	Id        int
+	SessionId int
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type TokenPair struct {
+	AccessToken      string    `json:"accessToken"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshToken     string    `json:"refreshToken"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+}
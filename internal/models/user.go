@@ -1,18 +1,48 @@
 package models
 
-import "github.com/golang-jwt/jwt"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
 
 type User struct {
 	Id       int    `json:"id"`
 	Login    string `json:"login"`
-	Password string `json:"password"`
+	Password string `json:"-"`
 	IsAdmin  bool   `json:"isAdmin"`
+	Disabled bool   `json:"disabled"`
+}
+
+type Credentials struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type UserPage struct {
+	Users []User `json:"users"`
+	Total int    `json:"total"`
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+}
+
+type PasswordReset struct {
+	ResetToken string    `json:"resetToken"`
+	ExpiresAt  time.Time `json:"expiresAt"`
 }
 
 type JwtClaims struct {
 	jwt.StandardClaims
-	UserId  int  `json:"userId"`
-	IsAdmin bool `json:"isAdmin"`
+	UserId    int  `json:"userId"`
+	IsAdmin   bool `json:"isAdmin"`
+	SessionId int  `json:"sid,omitempty"`
+	Mfa       bool `json:"mfa,omitempty"`
+	// AuthTime is the AuthTime of the session, in Unix seconds.
+	AuthTime int64 `json:"auth_time,omitempty"`
+	// ApiKeyId and Scopes are set when the request is authenticated with an
+	// API key instead of an access token; they never appear in a JWT.
+	ApiKeyId int      `json:"-"`
+	Scopes   []string `json:"-"`
 }
 
 type Role int
@@ -0,0 +1,9 @@
+package actors
+
+import "MovieService/internal/pkg/apperr"
+
+var (
+	ErrActorNotFound  = apperr.NotFound("actor_not_found", "actor not found")
+	ErrUnknownSorting = apperr.BadRequest("unknown_sorting", "unknown sorting")
+	ErrActorChanged   = apperr.PreconditionFailed("actor_changed", "the actor has been changed or deleted meanwhile")
+)
@@ -3,77 +3,102 @@ package http
 import (
 	"MovieService/internal/models"
 	"MovieService/internal/pkg/actors"
-	"MovieService/internal/pkg/middleware"
+	"MovieService/internal/pkg/utils/etag"
+	"MovieService/internal/pkg/utils/mergepatch"
+	"MovieService/internal/pkg/utils/pagination"
 	resp "MovieService/internal/pkg/utils/responser"
+	"MovieService/internal/pkg/utils/router"
+	"MovieService/internal/pkg/utils/sorting"
 	"encoding/json"
-	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
-	"path/filepath"
-	"regexp"
-	"strconv"
-)
-
-var (
-	allActorsRe   = regexp.MustCompile(`^\/api\/actors[\/]*$`)
-	addActorRe    = regexp.MustCompile(`^\/api\/actors[\/]*$`)
-	updateActorRe = regexp.MustCompile(`^\/api\/actors\/([0-9]*)$`)
-	deleteActorRe = regexp.MustCompile(`^\/api\/actors\/(\d+)$`)
 )
 
 type ActorsHandler struct {
-	log *slog.Logger
-	uc  actors.ActorsUsecase
+	uc actors.ActorsUsecase
 }
 
-func NewActorsHandler(log *slog.Logger, uc actors.ActorsUsecase) ActorsHandler {
+func NewActorsHandler(uc actors.ActorsUsecase) ActorsHandler {
 	return ActorsHandler{
-		log: log,
-		uc:  uc,
+		uc: uc,
 	}
 }
 
-func (ah *ActorsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("content-type", "application/json")
-	fmt.Println(r.URL.Path)
-	switch {
-	case r.Method == http.MethodGet && allActorsRe.MatchString(r.URL.Path):
-		middleware.RoleCheck(w, r, ah.GetActors, []models.Role{models.Client, models.Admin})
-		return
-	case r.Method == http.MethodPost && addActorRe.MatchString(r.URL.Path):
-		middleware.RoleCheck(w, r, ah.AddActor, []models.Role{models.Admin})
+// GetActors godoc
+// @Summary      Get list of actors
+// @Description  Retrieves a page of actors in the given order, each with their movies. The Link header points to the first, previous and next pages.
+// @Tags         Actors
+// @Produce      json
+// @Param        sort      query    string  false  "Sort order, fields separated by commas and descending with a minus, e.g. -movies,surname. Fields: surname, name, birth_date, movies (their number), id. id by default"
+// @Param        limit     query    int     false  "Page size, 20 by default and 100 at most"
+// @Param        offset    query    int     false  "Number of actors to skip, not combinable with cursor"
+// @Param        cursor    query    string  false  "Cursor of the page to read, the next field of the previous page"
+// @Param        count     query    bool    false  "Count all actors into total"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.ActorPage
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/actors [get]
+func (ah *ActorsHandler) GetActors(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r.URL.Query())
+	if err != nil {
+		resp.Error(w, r, err)
 		return
-	case r.Method == http.MethodPut && updateActorRe.MatchString(r.URL.Path):
-		middleware.RoleCheck(w, r, ah.UpdateActor, []models.Role{models.Admin})
+	}
+
+	spec := r.URL.Query().Get("sort")
+	if spec == "" {
+		spec = actors.DefaultSort
+	}
+
+	sort, err := sorting.Parse(spec, actors.SortFields...)
+	if err != nil {
+		resp.Error(w, r, err)
 		return
-	case r.Method == http.MethodDelete && deleteActorRe.MatchString(r.URL.Path):
-		middleware.RoleCheck(w, r, ah.DeleteActor, []models.Role{models.Admin})
+	}
+
+	actors, err := ah.uc.GetActors(r.Context(), sort, page)
+	if err != nil {
+		resp.Error(w, r, err)
 		return
-	default:
-		resp.JSONStatus(w, http.StatusNotFound)
 	}
+
+	pagination.SetLinks(w, r, page, actors.Next)
+	resp.JSON(w, http.StatusOK, actors)
 }
 
-// GetActors godoc
-// @Summary      Get list of actors
-// @Description  Retrieves a list of all actors
+// GetActor godoc
+// @Summary      Get actor by ID
+// @Description  Retrieves the actor with the given ID, answers 304 when If-None-Match lists its ETag
 // @Tags         Actors
-// @Accept       json
 // @Produce      json
-// @Success      200  {array}  models.Actor
-// @Failure      500
-// @Router       /api/actors [get]
-func (ah *ActorsHandler) GetActors(w http.ResponseWriter, r *http.Request) {
-	actors, err := ah.uc.GetActors(r.Context())
-	fmt.Println("get actors")
+// @Param        id  path  int  true  "Actor ID"
+// @Param        If-None-Match  header  string  false  "ETag of the cached actor"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.Actor
+// @Header       200  {string}  ETag  "ETag of the actor"
+// @Success      304
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/actors/{id} [get]
+func (ah *ActorsHandler) GetActor(w http.ResponseWriter, r *http.Request) {
+	actor, err := ah.uc.GetActor(r.Context(), router.Int(r, "id"))
 	if err != nil {
-		fmt.Println(err)
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		resp.Error(w, r, err)
 		return
 	}
 
-	resp.JSON(w, http.StatusOK, actors)
+	if etag.NotModified(w, r, actor) {
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, actor)
 }
 
 // AddActor godoc
@@ -82,26 +107,34 @@ func (ah *ActorsHandler) GetActors(w http.ResponseWriter, r *http.Request) {
 // @Tags         Actors
 // @Accept       json
 // @Param        actor  body  models.Actor  true  "Actor information"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Success      200
-// @Failure      400
-// @Failure      500
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      422  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
 // @Router       /api/actors [post]
 func (ah *ActorsHandler) AddActor(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("add actor")
 	body, err := io.ReadAll(r.Body)
 
 	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
+		badRequest(w)
 		return
 	}
 	defer r.Body.Close()
 
 	a := &models.Actor{}
 	err = json.Unmarshal(body, a)
+	if err != nil {
+		badRequest(w)
+		return
+	}
 
 	err = ah.uc.AddActor(r.Context(), a)
 	if err != nil {
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		resp.Error(w, r, err)
 		return
 	}
 
@@ -109,45 +142,93 @@ func (ah *ActorsHandler) AddActor(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateActor godoc
-// @Summary      Update actor by ID
-// @Description  Updates an actor with the given ID
+// @Summary      Replace actor by ID
+// @Description  Replaces every field of the actor with the given ID, the filmography is kept
 // @Tags         Actors
 // @Accept       json
+// @Produce      json
 // @Param        id  path  int  true  "Actor ID"
-// @Param        actor  body  models.Actor  true  "Actor information to update"
-// @Success      200
-// @Failure      400
-// @Failure      500
+// @Param        If-Match  header  string  false  "ETag of the actor as last read"
+// @Param        actor  body  models.Actor  true  "Actor information"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.Actor
+// @Header       200  {string}  ETag  "ETag of the actor"
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      412  {object}  resp.Problem
+// @Failure      422  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
 // @Router       /api/actors/{id} [put]
 func (ah *ActorsHandler) UpdateActor(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("update actors")
-	idStr := filepath.Base(r.URL.Path)
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
-		return
-	}
+	id := router.Int(r, "id")
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		badRequest(w)
 		return
 	}
 	defer r.Body.Close()
 
 	a := &models.Actor{}
 	err = json.Unmarshal(body, a)
+	if err != nil {
+		badRequest(w)
+		return
+	}
 	a.Id = id
-	fmt.Println(id)
 
-	err = ah.uc.UpdateActor(r.Context(), a)
+	updated, err := ah.uc.UpdateActor(r.Context(), a, etag.IfMatch(r))
+	if err != nil {
+		resp.Error(w, r, err)
+		return
+	}
+
+	etag.Set(w, updated)
+	resp.JSON(w, http.StatusOK, updated)
+}
+
+// PatchActor godoc
+// @Summary      Patch actor by ID
+// @Description  Changes the fields of the actor present in a JSON merge patch (RFC 7396), null clears a field
+// @Tags         Actors
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        id  path  int  true  "Actor ID"
+// @Param        If-Match  header  string  false  "ETag of the actor as last read"
+// @Param        actor  body  models.Actor  true  "Fields to change"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.Actor
+// @Header       200  {string}  ETag  "ETag of the actor"
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      412  {object}  resp.Problem
+// @Failure      415  {object}  resp.Problem
+// @Failure      422  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/actors/{id} [patch]
+func (ah *ActorsHandler) PatchActor(w http.ResponseWriter, r *http.Request) {
+	id := router.Int(r, "id")
 
+	patch := &models.ActorPatch{}
+	if err := mergepatch.Decode(r, patch); err != nil {
+		resp.Error(w, r, err)
+		return
+	}
+
+	updated, err := ah.uc.PatchActor(r.Context(), id, patch, etag.IfMatch(r))
 	if err != nil {
-		fmt.Println(err)
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		resp.Error(w, r, err)
 		return
 	}
 
-	resp.JSONStatus(w, http.StatusOK)
+	etag.Set(w, updated)
+	resp.JSON(w, http.StatusOK, updated)
 }
 
 // DeleteActor godoc
@@ -156,25 +237,30 @@ func (ah *ActorsHandler) UpdateActor(w http.ResponseWriter, r *http.Request) {
 // @Tags         Actors
 // @Accept       json
 // @Param        id  path  int  true  "Actor ID"
+// @Param        If-Match  header  string  false  "ETag of the actor as last read"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Success      200
-// @Failure      400
-// @Failure      500
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      412  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
 // @Router       /api/actors/{id} [delete]
 func (ah *ActorsHandler) DeleteActor(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("delete actors")
-	idStr := filepath.Base(r.URL.Path)
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
-		return
-	}
+	id := router.Int(r, "id")
 
-	err = ah.uc.DeleteActor(r.Context(), id)
+	err := ah.uc.DeleteActor(r.Context(), id, etag.IfMatch(r))
 
 	if err != nil {
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		resp.Error(w, r, err)
 		return
 	}
 
 	resp.JSONStatus(w, http.StatusOK)
 }
+
+func badRequest(w http.ResponseWriter) {
+	resp.Fail(w, http.StatusBadRequest, "invalid request body")
+}
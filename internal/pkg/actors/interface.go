@@ -2,20 +2,29 @@ package actors
 
 import (
 	"MovieService/internal/models"
+	"MovieService/internal/pkg/utils/etag"
 	"context"
 )
 
+// SortFields are the fields the actor listing can be sorted by, movies being
+// the number of movies of the actor.
+var SortFields = []string{"surname", "name", "birth_date", "movies", "id"}
+
+const DefaultSort = "id"
+
 type ActorsRepo interface {
-	ReadActors(context.Context) ([]models.Actor, error)
+	ReadActors(context.Context, []models.SortKey, *models.PageRequest) (*models.ActorPage, error)
 	ReadActor(context.Context, int) (*models.Actor, error)
 	CreateActor(context.Context, *models.Actor) error
 	UpdateActor(context.Context, *models.Actor) error
-	DeleteActor(context.Context, int) error
+	DeleteActor(context.Context, int, int) error
 }
 
 type ActorsUsecase interface {
-	GetActors(context.Context) ([]models.Actor, error)
+	GetActors(context.Context, []models.SortKey, *models.PageRequest) (*models.ActorPage, error)
+	GetActor(context.Context, int) (*models.Actor, error)
 	AddActor(context.Context, *models.Actor) error
-	UpdateActor(context.Context, *models.Actor) error
-	DeleteActor(context.Context, int) error
+	UpdateActor(context.Context, *models.Actor, etag.Precondition) (*models.Actor, error)
+	PatchActor(context.Context, int, *models.ActorPatch, etag.Precondition) (*models.Actor, error)
+	DeleteActor(context.Context, int, etag.Precondition) error
 }
@@ -0,0 +1,162 @@
+package repo
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/actors"
+	"MovieService/internal/pkg/utils/pagination"
+	"MovieService/internal/pkg/utils/sorting"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	moviesCount = "(SELECT count(*) FROM movie_actor AS ma WHERE ma.actor_id = a.id)"
+	listActors  = "SELECT a.id, a.name, a.surname, a.gender, a.birth_date, a.version, " + moviesCount + " FROM actor AS a"
+	countActors = "SELECT count(*) FROM actor AS a"
+)
+
+// sortColumns are the columns of the sort fields. Every order ends with the
+// id so that actors with equal values keep their place between pages.
+var sortColumns = map[string]string{
+	"surname":    "a.surname",
+	"name":       "a.name",
+	"birth_date": "a.birth_date",
+	"movies":     moviesCount,
+	"id":         "a.id",
+}
+
+func (ar *ActorsRepo) ReadActors(ctx context.Context, sort []models.SortKey, page *models.PageRequest) (*models.ActorPage, error) {
+	keys, ok := pagination.Keys(sort, sortColumns, "a.id")
+	if !ok {
+		return nil, actors.ErrUnknownSorting
+	}
+
+	result := &models.ActorPage{
+		Actors: make([]models.Actor, 0),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+
+	if page.Count {
+		var total int
+		err := ar.db.QueryRow(ctx, countActors).Scan(&total)
+		if err != nil {
+			err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	cond, args := "", []any{}
+	listing := "actors:" + sorting.String(sort)
+	if page.Cursor != "" {
+		after := make([]any, len(keys))
+		for i, key := range keys {
+			after[i] = keyDest(key.Column)
+		}
+
+		if err := pagination.DecodeCursor(page.Cursor, listing, after...); err != nil {
+			return nil, err
+		}
+
+		cond, args = " WHERE "+pagination.After(keys, 1), after
+	}
+
+	// one more row than asked tells whether there is a next page
+	query := fmt.Sprintf("%s%s ORDER BY %s LIMIT %d OFFSET %d",
+		listActors, cond, pagination.Order(keys), page.Limit+1, page.Offset)
+	rows, err := ar.db.Query(ctx, query, args...)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Query: %w", err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	actor := models.Actor{}
+	counts := make([]int, 0)
+	var count int
+	for rows.Next() {
+		err = rows.Scan(
+			&actor.Id,
+			&actor.Name,
+			&actor.Surname,
+			&actor.Gender,
+			&actor.BirthDate,
+			&actor.Version,
+			&count,
+		)
+		if err != nil {
+			err = fmt.Errorf("error happened in rows.Scan: %w", err)
+
+			return nil, err
+		}
+
+		result.Actors = append(result.Actors, actor)
+		counts = append(counts, count)
+	}
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error happened in rows.Next: %w", err)
+
+		return nil, err
+	}
+	rows.Close()
+
+	if len(result.Actors) > page.Limit {
+		result.Actors = result.Actors[:page.Limit]
+		last := &result.Actors[page.Limit-1]
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = keyValue(last, counts[page.Limit-1], key.Column)
+		}
+		result.Next = pagination.EncodeCursor(listing, values...)
+	}
+
+	ids := make([]int, len(result.Actors))
+	for i := range result.Actors {
+		ids[i] = result.Actors[i].Id
+	}
+
+	filmographies, err := ar.readFilmographies(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.Actors {
+		result.Actors[i].Movies = filmographies[result.Actors[i].Id]
+	}
+
+	return result, nil
+}
+
+// keyValue and keyDest are the value of a sort column for the cursor of an
+// actor playing in count movies and the destination to decode it from a
+// cursor.
+func keyValue(a *models.Actor, count int, column string) any {
+	switch column {
+	case "a.surname":
+		return a.Surname
+	case "a.name":
+		return a.Name
+	case "a.birth_date":
+		return a.BirthDate
+	case moviesCount:
+		return count
+	}
+
+	return a.Id
+}
+
+func keyDest(column string) any {
+	switch column {
+	case "a.surname", "a.name":
+		return new(string)
+	case "a.birth_date":
+		return new(pgtype.Date)
+	}
+
+	return new(int)
+}
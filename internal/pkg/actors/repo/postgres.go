@@ -2,6 +2,7 @@ package repo
 
 import (
 	"MovieService/internal/models"
+	"MovieService/internal/pkg/actors"
 	"context"
 	"errors"
 	"fmt"
@@ -10,12 +11,11 @@ import (
 )
 
 const (
-	readActors        = "SELECT id, name, surname, gender, birth_date FROM actor;"
-	readActor         = "SELECT name, surname, gender, birth_date FROM actor WHERE id=$1;"
-	createActor       = "INSERT INTO actor (name, surname, gender, birth_date) VALUES ($1, $2, $3, $4);"
-	updateActor       = "UPDATE actor SET name=$1, surname=$2, gender=$3, birth_date=$4 WHERE id=$5;"
-	deleteActor       = "DELETE FROM actor WHERE id=$1;"
-	readMoviesOfActor = "SELECT m.id, m.name, m.description, m.release_date, m.rating FROM movie AS m JOIN movie_actor AS ma ON ma.movie_id = m.id WHERE ma.actor_id=$1"
+	readActor          = "SELECT name, surname, gender, birth_date, version FROM actor WHERE id=$1;"
+	createActor        = "INSERT INTO actor (name, surname, gender, birth_date) VALUES ($1, $2, $3, $4) RETURNING id;"
+	updateActor        = "UPDATE actor SET name=$1, surname=$2, gender=$3, birth_date=$4, version=version+1 WHERE id=$5 AND version=$6;"
+	deleteActor        = "DELETE FROM actor WHERE id=$1 AND version=$2;"
+	readMoviesOfActors = "SELECT ma.actor_id, m.id, m.name, m.description, m.release_date, m.rating FROM movie AS m JOIN movie_actor AS ma ON ma.movie_id = m.id WHERE ma.actor_id = ANY($1) ORDER BY ma.actor_id, m.id"
 )
 
 type ActorsRepo struct {
@@ -28,86 +28,82 @@ func NewActorsRepo(db *pgxpool.Pool) *ActorsRepo {
 	}
 }
 
-func (ar *ActorsRepo) ReadActors(ctx context.Context) ([]models.Actor, error) {
-	actorSlice := make([]models.Actor, 0)
-	rows, err := ar.db.Query(ctx, readActors)
+// readFilmographies loads the movies of all the actors in one query, so that
+// a page of actors costs the same two queries whatever its size. Every actor
+// gets a filmography, empty when they play nowhere.
+func (ar *ActorsRepo) readFilmographies(ctx context.Context, actorIds []int) (map[int][]models.MovieInActorSlice, error) {
+	filmographies := make(map[int][]models.MovieInActorSlice, len(actorIds))
+	for _, id := range actorIds {
+		filmographies[id] = make([]models.MovieInActorSlice, 0)
+	}
+	if len(actorIds) == 0 {
+		return filmographies, nil
+	}
+
+	rows, err := ar.db.Query(ctx, readMoviesOfActors, actorIds)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return []models.Actor{}, err
-		}
-		err = fmt.Errorf("error happened in db.QueryContext: %w", err)
+		err = fmt.Errorf("error happened in db.Query: %w", err)
 
-		return []models.Actor{}, err
+		return nil, err
 	}
-	actor := models.Actor{}
+	defer rows.Close()
+
+	var actorId int
+	movie := models.MovieInActorSlice{}
 	for rows.Next() {
 		err = rows.Scan(
-			&actor.Id,
-			&actor.Name,
-			&actor.Surname,
-			&actor.Gender,
-			&actor.BirthDate,
+			&actorId,
+			&movie.Id,
+			&movie.Name,
+			&movie.Description,
+			&movie.ReleaseDate,
+			&movie.Rating,
 		)
 		if err != nil {
 			err = fmt.Errorf("error happened in rows.Scan: %w", err)
 
-			return []models.Actor{}, err
+			return nil, err
 		}
 
-		movieRows, err := ar.db.Query(ctx, readMoviesOfActor, actor.Id)
-		if err != nil {
-			err = fmt.Errorf("error happened in rows.Scan: %w", err)
-
-			return []models.Actor{}, err
-		}
-
-		movieSlice := make([]models.MovieInActorSlice, 0)
-		movie := models.MovieInActorSlice{}
-		for movieRows.Next() {
-			err = movieRows.Scan(
-				&movie.Id,
-				&movie.Name,
-				&movie.Description,
-				&movie.ReleaseDate,
-				&movie.Rating,
-			)
-
-			if err != nil {
-				err = fmt.Errorf("error happened in rows.Scan: %w", err)
-				return []models.Actor{}, err
-			}
-
-			movieSlice = append(movieSlice, movie)
-		}
-		actor.Movies = movieSlice
+		filmographies[actorId] = append(filmographies[actorId], movie)
+	}
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error happened in rows.Next: %w", err)
 
-		actorSlice = append(actorSlice, actor)
+		return nil, err
 	}
-	defer rows.Close()
 
-	return actorSlice, nil
+	return filmographies, nil
 }
 
 func (ar *ActorsRepo) ReadActor(ctx context.Context, id int) (*models.Actor, error) {
 	a := &models.Actor{Id: id}
-	if err := ar.db.QueryRow(ctx, readActor, id).
-		Scan(&a.Name, &a.Surname, &a.Gender, &a.BirthDate); err != nil {
+	err := ar.db.QueryRow(ctx, readActor, id).
+		Scan(&a.Name, &a.Surname, &a.Gender, &a.BirthDate, &a.Version)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &models.Actor{}, err
+			return &models.Actor{}, actors.ErrActorNotFound
 		}
 		err = fmt.Errorf("error happened in row.Scan: %w", err)
 
 		return &models.Actor{}, err
 	}
+
+	filmographies, err := ar.readFilmographies(ctx, []int{id})
+	if err != nil {
+		return &models.Actor{}, err
+	}
+	a.Movies = filmographies[id]
+
 	return a, nil
 }
 
 func (ar *ActorsRepo) CreateActor(ctx context.Context, actor *models.Actor) error {
-	_, err := ar.db.Exec(ctx, createActor,
-		actor.Name, actor.Surname, actor.Gender, actor.BirthDate)
+	err := ar.db.QueryRow(ctx, createActor,
+		actor.Name, actor.Surname, actor.Gender, actor.BirthDate).Scan(&actor.Id)
 
 	if err != nil {
-		err = fmt.Errorf("error happened in db.Exec: %w", err)
+		err = fmt.Errorf("error happened in scan.Scan: %w", err)
 
 		return err
 	}
@@ -116,23 +112,33 @@ func (ar *ActorsRepo) CreateActor(ctx context.Context, actor *models.Actor) erro
 }
 
 func (ar *ActorsRepo) UpdateActor(ctx context.Context, actor *models.Actor) error {
-	_, err := ar.db.Exec(ctx, updateActor, actor.Name, actor.Surname, actor.Gender, actor.BirthDate, actor.Id)
+	tag, err := ar.db.Exec(ctx, updateActor, actor.Name, actor.Surname, actor.Gender, actor.BirthDate, actor.Id, actor.Version)
 	if err != nil {
 		err = fmt.Errorf("error happened in db.Exec: %w", err)
 
 		return err
 	}
 
+	// the version is the one read before the change, someone else got in
+	// between
+	if tag.RowsAffected() == 0 {
+		return actors.ErrActorChanged
+	}
+
 	return nil
 }
 
-func (ar *ActorsRepo) DeleteActor(ctx context.Context, id int) error {
-	_, err := ar.db.Exec(ctx, deleteActor, id)
+func (ar *ActorsRepo) DeleteActor(ctx context.Context, id int, version int) error {
+	tag, err := ar.db.Exec(ctx, deleteActor, id, version)
 	if err != nil {
 		err = fmt.Errorf("error happened in db.Exec: %w", err)
 
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return actors.ErrActorChanged
+	}
+
 	return nil
 }
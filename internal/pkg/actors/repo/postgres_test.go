@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"MovieService/internal/pkg/utils/pgtest"
+	"context"
+	"fmt"
+	"testing"
+)
+
+// BenchmarkReadFilmographies compares loading the filmographies of a page of
+// actors one actor at a time, a query per actor, with loading them in one
+// batch.
+func BenchmarkReadFilmographies(b *testing.B) {
+	db := pgtest.Open(b)
+	pgtest.Seed(b, db, 200, 100, 4)
+	ar := NewActorsRepo(db)
+	ctx := context.Background()
+
+	for _, size := range []int{1, 20, 100} {
+		ids := make([]int, size)
+		for i := range ids {
+			ids[i] = i + 1
+		}
+
+		b.Run(fmt.Sprintf("per-row/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, id := range ids {
+					if _, err := ar.readFilmographies(ctx, []int{id}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("batched/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ar.readFilmographies(ctx, ids); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
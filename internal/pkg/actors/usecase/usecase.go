@@ -3,62 +3,116 @@ package usecase
 import (
 	"MovieService/internal/models"
 	"MovieService/internal/pkg/actors"
+	"MovieService/internal/pkg/audit"
+	"MovieService/internal/pkg/utils/etag"
+	"MovieService/internal/pkg/utils/validator"
 	"context"
-	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type ActorsUsecase struct {
-	repo actors.ActorsRepo
+	repo  actors.ActorsRepo
+	audit audit.Recorder
 }
 
-func NewActorsUsecase(repo actors.ActorsRepo) *ActorsUsecase {
+func NewActorsUsecase(repo actors.ActorsRepo, recorder audit.Recorder) *ActorsUsecase {
 	return &ActorsUsecase{
-		repo: repo,
+		repo:  repo,
+		audit: recorder,
 	}
 }
 
-func (au *ActorsUsecase) GetActors(ctx context.Context) ([]models.Actor, error) {
-	actors, err := au.repo.ReadActors(ctx)
-	if err != nil {
-		return make([]models.Actor, 0), err
-	}
-	return actors, nil
+func (au *ActorsUsecase) GetActors(ctx context.Context, sort []models.SortKey, page *models.PageRequest) (*models.ActorPage, error) {
+	return au.repo.ReadActors(ctx, sort, page)
 }
 
 func (au *ActorsUsecase) AddActor(ctx context.Context, actor *models.Actor) error {
+	if err := validator.Struct(actor); err != nil {
+		return err
+	}
+
 	err := au.repo.CreateActor(ctx, actor)
 	if err != nil {
 		return err
 	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "create", Entity: audit.EntityActor, EntityId: actor.Id, After: actor})
 	return nil
 }
 
-func (au *ActorsUsecase) UpdateActor(ctx context.Context, actor *models.Actor) error {
-	a, err := au.repo.ReadActor(ctx, actor.Id)
-	if actor.Name != "" {
-		a.Name = actor.Name
+// UpdateActor replaces every field of the actor but the filmography.
+func (au *ActorsUsecase) UpdateActor(ctx context.Context, actor *models.Actor, cond etag.Precondition) (*models.Actor, error) {
+	before, err := au.repo.ReadActor(ctx, actor.Id)
+	if err != nil {
+		return nil, err
 	}
 
-	if actor.Surname != "" {
-		a.Surname = actor.Surname
+	if err = cond.Check(before); err != nil {
+		return nil, err
 	}
 
-	if actor.Gender != "" {
-		a.Gender = actor.Gender
+	if err = validator.Struct(actor); err != nil {
+		return nil, err
 	}
 
-	if actor.BirthDate != (pgtype.Date{}) {
-		a.BirthDate = actor.BirthDate
+	actor.Version = before.Version
+
+	return au.update(ctx, before, actor)
+}
+
+// PatchActor changes only the fields present in the patch.
+func (au *ActorsUsecase) PatchActor(ctx context.Context, id int, patch *models.ActorPatch, cond etag.Precondition) (*models.Actor, error) {
+	before, err := au.repo.ReadActor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cond.Check(before); err != nil {
+		return nil, err
+	}
+
+	a := *before
+	patch.Apply(&a)
+	if err = validator.Struct(&a); err != nil {
+		return nil, err
 	}
 
-	err = au.repo.UpdateActor(ctx, a)
+	return au.update(ctx, before, &a)
+}
+
+func (au *ActorsUsecase) update(ctx context.Context, before *models.Actor, a *models.Actor) (*models.Actor, error) {
+	err := au.repo.UpdateActor(ctx, a)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+
+	after, err := au.repo.ReadActor(ctx, a.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "update", Entity: audit.EntityActor, EntityId: a.Id, Before: before, After: after})
+	return after, nil
 }
 
-func (au *ActorsUsecase) DeleteActor(ctx context.Context, id int) error {
-	err := au.repo.DeleteActor(ctx, id)
-	return err
+func (au *ActorsUsecase) GetActor(ctx context.Context, id int) (*models.Actor, error) {
+	return au.repo.ReadActor(ctx, id)
+}
+
+func (au *ActorsUsecase) DeleteActor(ctx context.Context, id int, cond etag.Precondition) error {
+	before, err := au.repo.ReadActor(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = cond.Check(before); err != nil {
+		return err
+	}
+
+	err = au.repo.DeleteActor(ctx, id, before.Version)
+	if err != nil {
+		return err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "delete", Entity: audit.EntityActor, EntityId: id, Before: before})
+	return nil
 }
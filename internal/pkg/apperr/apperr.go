@@ -0,0 +1,117 @@
+package apperr
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Kinds of domain errors. Usecases return an *Error of one of these kinds and
+// the HTTP layer picks the status code by the kind alone.
+var (
+	ErrBadRequest      = errors.New("bad request")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrPrecondition    = errors.New("precondition failed")
+	ErrValidation      = errors.New("validation failed")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrMediaType       = errors.New("unsupported media type")
+)
+
+const CodeValidation = "validation_failed"
+
+// Error is a domain error. Code is a stable machine-readable identifier,
+// Message is meant for people and Fields holds a message per invalid field,
+// keyed by the JSON name of the field.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  map[string]string
+	Err     error
+}
+
+func New(kind error, code string, msg string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: msg,
+	}
+}
+
+func BadRequest(code string, msg string) *Error {
+	return New(ErrBadRequest, code, msg)
+}
+
+func Unauthorized(code string, msg string) *Error {
+	return New(ErrUnauthorized, code, msg)
+}
+
+func Forbidden(code string, msg string) *Error {
+	return New(ErrForbidden, code, msg)
+}
+
+func NotFound(code string, msg string) *Error {
+	return New(ErrNotFound, code, msg)
+}
+
+func Conflict(code string, msg string) *Error {
+	return New(ErrConflict, code, msg)
+}
+
+func PreconditionFailed(code string, msg string) *Error {
+	return New(ErrPrecondition, code, msg)
+}
+
+func TooManyRequests(code string, msg string) *Error {
+	return New(ErrTooManyRequests, code, msg)
+}
+
+func UnsupportedMediaType(code string, msg string) *Error {
+	return New(ErrMediaType, code, msg)
+}
+
+func Validation(fields map[string]string) *Error {
+	e := New(ErrValidation, CodeValidation, ErrValidation.Error())
+	e.Fields = fields
+	return e
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	msgs := make([]string, 0, len(e.Fields))
+	for field, msg := range e.Fields {
+		msgs = append(msgs, field+": "+msg)
+	}
+	sort.Strings(msgs)
+
+	return e.Message + ": " + strings.Join(msgs, "; ")
+}
+
+// Is makes errors.Is(err, apperr.ErrNotFound) and the like hold for every
+// error of the kind, and errors.Is(err, movies.ErrMovieNotFound) for every
+// error with the code of that one, wrapped or not.
+func (e *Error) Is(target error) bool {
+	if t, ok := target.(*Error); ok {
+		return t.Kind == e.Kind && t.Code == e.Code
+	}
+
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns a copy of the error with err as the cause, so that the cause
+// gets logged while the response shows only the domain error.
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
@@ -0,0 +1,170 @@
+package http
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/audit"
+	"MovieService/internal/pkg/utils/logger"
+	resp "MovieService/internal/pkg/utils/responser"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// exportTimeout bounds an export, the rows are read while the client receives
+// them, so a slow client would otherwise hold a database connection for good.
+const exportTimeout = 5 * time.Minute
+
+type AuditHandler struct {
+	uc audit.AuditUsecase
+}
+
+func NewAuditHandler(uc audit.AuditUsecase) AuditHandler {
+	return AuditHandler{
+		uc: uc,
+	}
+}
+
+// GetEvents godoc
+// @Summary      Query the audit log
+// @Description  Retrieves a page of security events, newest first
+// @Tags         Audit
+// @Produce      json
+// @Param        userId    query  int     false  "User who did the action"
+// @Param        action    query  string  false  "Action, e.g. sign_in or delete"
+// @Param        entity    query  string  false  "Entity, e.g. user, movie or actor"
+// @Param        entityId  query  string  false  "Id of the entity"
+// @Param        from      query  string  false  "Start of the time range, RFC 3339, inclusive"
+// @Param        to        query  string  false  "End of the time range, RFC 3339, exclusive"
+// @Param        page      query  int     false  "Page number, starting from 1"
+// @Param        limit     query  int     false  "Page size, 50 by default and 500 at most"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.AuditPage
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/audit [get]
+func (ah *AuditHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, fields := parseFilter(query)
+	if fields != nil {
+		resp.FailFields(w, http.StatusBadRequest, "invalid filter", fields)
+		return
+	}
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	events, err := ah.uc.ListEvents(r.Context(), filter, page, limit)
+	if err != nil {
+		resp.Error(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, events)
+}
+
+// ExportEvents godoc
+// @Summary      Export the audit log
+// @Description  Streams every matching event, oldest first, as newline-delimited JSON. The export is cut off after 5 minutes, narrow the time range for bigger logs
+// @Tags         Audit
+// @Produce      application/x-ndjson
+// @Param        userId    query  int     false  "User who did the action"
+// @Param        action    query  string  false  "Action, e.g. sign_in or delete"
+// @Param        entity    query  string  false  "Entity, e.g. user, movie or actor"
+// @Param        entityId  query  string  false  "Id of the entity"
+// @Param        from      query  string  false  "Start of the time range, RFC 3339, inclusive"
+// @Param        to        query  string  false  "End of the time range, RFC 3339, exclusive"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.AuditEvent
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/audit/export [get]
+func (ah *AuditHandler) ExportEvents(w http.ResponseWriter, r *http.Request) {
+	filter, fields := parseFilter(r.URL.Query())
+	if fields != nil {
+		resp.FailFields(w, http.StatusBadRequest, "invalid filter", fields)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), exportTimeout)
+	defer cancel()
+	// the deadline of the context does not interrupt a blocked write
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportTimeout))
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.ndjson"`)
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	written := 0
+
+	err := ah.uc.ExportEvents(ctx, filter, func(e *models.AuditEvent) error {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+
+		written++
+		if flusher != nil && written%100 == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// once the first line is out the status cannot change anymore, the
+		// client sees a truncated stream
+		logger.FromContext(r.Context()).Error("failed to export audit events", "written", written, "error", err)
+		if written == 0 {
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Disposition")
+			resp.Fail(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+}
+
+// parseFilter reads the filter of the query string and returns a message per
+// invalid parameter.
+func parseFilter(query url.Values) (*models.AuditFilter, map[string]string) {
+	fields := make(map[string]string)
+	filter := &models.AuditFilter{
+		Action:   query.Get("action"),
+		Entity:   query.Get("entity"),
+		EntityId: query.Get("entityId"),
+	}
+
+	if v := query.Get("userId"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 1 {
+			fields["userId"] = "must be a positive integer"
+		}
+		filter.UserId = id
+	}
+
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				fields[name] = "must be an RFC 3339 time like 2024-01-02T15:04:05Z"
+				continue
+			}
+			*dst = &t
+		}
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		fields["to"] = "must be after from"
+	}
+
+	if len(fields) != 0 {
+		return nil, fields
+	}
+
+	return filter, nil
+}
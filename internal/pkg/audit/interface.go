@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"MovieService/internal/models"
+	"context"
+)
+
+// Entities the events are about.
+const (
+	EntityUser     = "user"
+	EntitySession  = "session"
+	EntityLogin    = "login"
+	EntityLockout  = "lockout"
+	EntityApiKey   = "api_key"
+	EntityTOTP     = "totp"
+	EntityIdentity = "identity"
+	EntityMovie    = "movie"
+	EntityActor    = "actor"
+)
+
+// Entry is what a usecase reports. The actor, address, user agent and request
+// id are taken from the context by the recorder.
+type Entry struct {
+	// ActorId overrides the user of the request, e.g. for a sign-in, where
+	// the user is known only once it succeeded.
+	ActorId  int
+	Action   string
+	Entity   string
+	EntityId interface{}
+	Before   interface{}
+	After    interface{}
+}
+
+// Recorder is how the other packages write to the audit log. Recording is
+// best effort: a failure is logged and never fails the action itself.
+type Recorder interface {
+	Record(context.Context, Entry)
+}
+
+type AuditRepo interface {
+	CreateEvent(context.Context, *models.AuditEvent) error
+	ListEvents(context.Context, *models.AuditFilter) ([]models.AuditEvent, int, error)
+	ExportEvents(context.Context, *models.AuditFilter, func(*models.AuditEvent) error) error
+}
+
+type AuditUsecase interface {
+	Recorder
+	ListEvents(context.Context, *models.AuditFilter, int, int) (*models.AuditPage, error)
+	ExportEvents(context.Context, *models.AuditFilter, func(*models.AuditEvent) error) error
+}
@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"MovieService/internal/models"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"strings"
+)
+
+const (
+	createEvent = `INSERT INTO audit_log (actor_id, action, entity, entity_id, before, after, ip, user_agent, request_id) ` +
+		`VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, '')) RETURNING id, time;`
+	eventColumns = `id, time, actor_id, action, entity, COALESCE(entity_id, ''), before, after, ` +
+		`COALESCE(ip, ''), COALESCE(user_agent, ''), COALESCE(request_id, '')`
+)
+
+type AuditRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepo(db *pgxpool.Pool) *AuditRepo {
+	return &AuditRepo{
+		db: db,
+	}
+}
+
+func (ar *AuditRepo) CreateEvent(ctx context.Context, e *models.AuditEvent) error {
+	err := ar.db.QueryRow(ctx, createEvent, e.ActorId, e.Action, e.Entity, e.EntityId,
+		nullJSON(e.Before), nullJSON(e.After), e.IP, e.UserAgent, e.RequestId).Scan(&e.Id, &e.Time)
+	if err != nil {
+		err = fmt.Errorf("error happened in scan.Scan: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ar *AuditRepo) ListEvents(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEvent, int, error) {
+	where, args := conditions(filter)
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`SELECT %s, count(*) OVER() FROM audit_log%s ORDER BY time DESC, id DESC LIMIT $%d OFFSET $%d;`,
+		eventColumns, where, len(args)-1, len(args))
+
+	rows, err := ar.db.Query(ctx, query, args...)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Query: %w", err)
+
+		return []models.AuditEvent{}, 0, err
+	}
+	defer rows.Close()
+
+	events := make([]models.AuditEvent, 0)
+	total := 0
+	for rows.Next() {
+		e := models.AuditEvent{}
+		err = rows.Scan(&e.Id, &e.Time, &e.ActorId, &e.Action, &e.Entity, &e.EntityId, &e.Before, &e.After,
+			&e.IP, &e.UserAgent, &e.RequestId, &total)
+		if err != nil {
+			err = fmt.Errorf("error happened in rows.Scan: %w", err)
+
+			return []models.AuditEvent{}, 0, err
+		}
+
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error happened in rows.Next: %w", err)
+
+		return []models.AuditEvent{}, 0, err
+	}
+
+	return events, total, nil
+}
+
+// ExportEvents calls fn for every matching event while the rows are read, so
+// the whole log is never held in memory.
+func (ar *AuditRepo) ExportEvents(ctx context.Context, filter *models.AuditFilter, fn func(*models.AuditEvent) error) error {
+	where, args := conditions(filter)
+	query := fmt.Sprintf(`SELECT %s FROM audit_log%s ORDER BY id;`, eventColumns, where)
+
+	rows, err := ar.db.Query(ctx, query, args...)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Query: %w", err)
+
+		return err
+	}
+	defer rows.Close()
+
+	e := models.AuditEvent{}
+	_, err = pgx.ForEachRow(rows, []any{&e.Id, &e.Time, &e.ActorId, &e.Action, &e.Entity, &e.EntityId,
+		&e.Before, &e.After, &e.IP, &e.UserAgent, &e.RequestId}, func() error {
+		return fn(&e)
+	})
+	if err != nil {
+		err = fmt.Errorf("error happened in rows.Next: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// conditions translates the filter into a WHERE clause with positional
+// arguments.
+func conditions(filter *models.AuditFilter) (string, []any) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UserId != 0 {
+		add("actor_id = $%d", filter.UserId)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.Entity != "" {
+		add("entity = $%d", filter.Entity)
+	}
+	if filter.EntityId != "" {
+		add("entity_id = $%d", filter.EntityId)
+	}
+	if filter.From != nil {
+		add("time >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("time < $%d", *filter.To)
+	}
+
+	if len(where) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+func nullJSON(raw []byte) []byte {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	return raw
+}
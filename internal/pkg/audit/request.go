@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"MovieService/internal/pkg/middleware"
+	"context"
+	"net"
+	"net/http"
+)
+
+type ctxKey int
+
+const (
+	requestInfoKey ctxKey = iota
+)
+
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+	RequestId string
+}
+
+// WithRequestInfo keeps the client details of the request in the context, so
+// that usecases deep down can record them.
+func WithRequestInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		info := RequestInfo{
+			IP:        host,
+			UserAgent: r.UserAgent(),
+			RequestId: middleware.RequestIdFromContext(r.Context()),
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestInfoKey, info)))
+	})
+}
+
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey).(RequestInfo)
+	return info
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/audit"
+	"MovieService/internal/pkg/middleware"
+	"MovieService/internal/pkg/utils/logger"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	DefaultEventsLimit = 50
+	MaxEventsLimit     = 500
+)
+
+type AuditUsecase struct {
+	repo audit.AuditRepo
+}
+
+func NewAuditUsecase(repo audit.AuditRepo) *AuditUsecase {
+	return &AuditUsecase{
+		repo: repo,
+	}
+}
+
+func (au *AuditUsecase) Record(ctx context.Context, entry audit.Entry) {
+	info := audit.RequestInfoFromContext(ctx)
+	event := &models.AuditEvent{
+		Action:    entry.Action,
+		Entity:    entry.Entity,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		RequestId: info.RequestId,
+	}
+
+	actorId := entry.ActorId
+	if claims, ok := middleware.ClaimsFromContext(ctx); ok && actorId == 0 {
+		actorId = claims.UserId
+	}
+	if actorId != 0 {
+		event.ActorId = &actorId
+	}
+
+	if entry.EntityId != nil {
+		event.EntityId = fmt.Sprint(entry.EntityId)
+	}
+
+	var err error
+	if event.Before, err = snapshot(entry.Before); err == nil {
+		event.After, err = snapshot(entry.After)
+	}
+	if err == nil {
+		err = au.repo.CreateEvent(ctx, event)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to record audit event",
+			"action", event.Action, "entity", event.Entity, "entityId", event.EntityId, "error", err)
+	}
+}
+
+func (au *AuditUsecase) ListEvents(ctx context.Context, filter *models.AuditFilter, page int, limit int) (*models.AuditPage, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = DefaultEventsLimit
+	}
+
+	if limit > MaxEventsLimit {
+		limit = MaxEventsLimit
+	}
+
+	filter.Limit = limit
+	filter.Offset = (page - 1) * limit
+
+	events, total, err := au.repo.ListEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuditPage{
+		Events: events,
+		Total:  total,
+		Page:   page,
+		Limit:  limit,
+	}, nil
+}
+
+// ExportEvents streams every matching event, oldest first.
+func (au *AuditUsecase) ExportEvents(ctx context.Context, filter *models.AuditFilter, fn func(*models.AuditEvent) error) error {
+	filter.Limit = 0
+	filter.Offset = 0
+
+	return au.repo.ExportEvents(ctx, filter, fn)
+}
+
+func snapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(v)
+}
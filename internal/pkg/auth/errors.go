@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"MovieService/internal/pkg/apperr"
+	"time"
+)
+
+var (
+	ErrInvalidCredentials  = apperr.Unauthorized("invalid_credentials", "invalid credentials")
+	ErrUserNotFound        = apperr.NotFound("user_not_found", "user not found")
+	ErrInvalidRefreshToken = apperr.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	ErrRefreshTokenReused  = apperr.Unauthorized("refresh_token_reused", "refresh token reused")
+	ErrTokenRevoked        = apperr.Unauthorized("token_revoked", "token revoked")
+	ErrUserDisabled        = apperr.Forbidden("user_disabled", "user disabled")
+	ErrInvalidResetToken   = apperr.BadRequest("invalid_reset_token", "invalid password reset token")
+	ErrLoginTaken          = apperr.Conflict("login_taken", "login is already taken")
+	ErrInvalidApiKey       = apperr.Unauthorized("invalid_api_key", "invalid API key")
+	ErrApiKeyNotFound      = apperr.NotFound("api_key_not_found", "API key not found")
+	ErrInvalidOIDCState    = apperr.BadRequest("invalid_oidc_state", "invalid or expired OIDC state")
+	ErrIdentityLinked      = apperr.Conflict("identity_linked", "identity is already linked to an account")
+	ErrTOTPNotEnrolled     = apperr.BadRequest("totp_not_enrolled", "two-factor authentication is not enabled")
+	ErrTOTPEnabled         = apperr.Conflict("totp_enabled", "two-factor authentication is already enabled")
+	ErrInvalidOTP          = apperr.Unauthorized("invalid_otp", "invalid authentication code")
+	ErrInvalidChallenge    = apperr.Unauthorized("invalid_challenge", "invalid or expired sign-in challenge")
+	ErrTooManyAttempts     = apperr.TooManyRequests("too_many_attempts", "too many failed sign-in attempts")
+	ErrReauthRequired      = apperr.Forbidden("reauthentication_required", "sign in again to confirm, the account has no password")
+)
+
+// LockedError is returned while sign-in is blocked for the login or the
+// client address.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/middleware"
+	resp "MovieService/internal/pkg/utils/responser"
+	"MovieService/internal/pkg/utils/router"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+type createApiKeyRequest struct {
+	Name        string     `json:"name"`
+	Permissions []string   `json:"permissions"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+}
+
+// sessionClaims returns the claims of a request authenticated with an access
+// token. API keys cannot be used to manage API keys.
+func sessionClaims(w http.ResponseWriter, r *http.Request) (*models.JwtClaims, bool) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		resp.Fail(w, http.StatusUnauthorized, "authentication required")
+		return nil, false
+	}
+
+	if claims.ApiKeyId != 0 {
+		resp.Fail(w, http.StatusForbidden, "API keys can only be managed with an access token")
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// CreateApiKey godoc
+// @Summary      Create API key
+// @Description  Creates a key for machine clients, sent in the X-API-Key header. Permissions narrow down the ones of the user role. The key is returned only once
+// @Tags         API keys
+// @Accept       json
+// @Produce      json
+// @Param        key  body  createApiKeyRequest  true  "Name, permissions and optional expiry"
+// @Security     BearerAuth
+// @Success      201  {object}  models.NewApiKey
+// @Failure      400  {object}  resp.Problem
+// @Failure      422  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/api-keys [post]
+func (ah *AuthHandler) CreateApiKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w)
+		return
+	}
+	defer r.Body.Close()
+
+	req := createApiKeyRequest{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		badRequest(w)
+		return
+	}
+
+	key := &models.ApiKey{Name: req.Name, Permissions: req.Permissions, ExpiresAt: req.ExpiresAt}
+
+	created, err := ah.uc.CreateApiKey(r.Context(), claims, key)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusCreated, created)
+}
+
+// GetApiKeys godoc
+// @Summary      Get API keys
+// @Description  Lists the API keys of the current user, including revoked ones, with the time each was last used
+// @Tags         API keys
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}  models.ApiKey
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/api-keys [get]
+func (ah *AuthHandler) GetApiKeys(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := ah.uc.ListApiKeys(r.Context(), claims)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, keys)
+}
+
+// RevokeApiKey godoc
+// @Summary      Revoke API key
+// @Tags         API keys
+// @Param        id  path  int  true  "API key ID"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/api-keys/{id} [delete]
+func (ah *AuthHandler) RevokeApiKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+
+	id := router.Int(r, "id")
+
+	err := ah.uc.RevokeApiKey(r.Context(), claims, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
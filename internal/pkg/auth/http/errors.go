@@ -0,0 +1,26 @@
+package http
+
+import (
+	"MovieService/internal/pkg/auth"
+	resp "MovieService/internal/pkg/utils/responser"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// writeError answers with the problem matching the auth error and, while
+// sign-in is locked, tells the client when to retry.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var lockedErr *auth.LockedError
+	if errors.As(err, &lockedErr) {
+		seconds := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	}
+
+	resp.Error(w, r, err)
+}
+
+func badRequest(w http.ResponseWriter) {
+	resp.Fail(w, http.StatusBadRequest, "invalid request body")
+}
@@ -3,133 +3,476 @@ package http
 import (
 	"MovieService/internal/models"
 	"MovieService/internal/pkg/auth"
-	"MovieService/internal/pkg/utils/jwt"
+	"MovieService/internal/pkg/middleware"
 	resp "MovieService/internal/pkg/utils/responser"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
-	"log/slog"
+	"net"
 	"net/http"
-	"regexp"
 )
 
-var (
-	signinRe = regexp.MustCompile(`^\/api\/auth\/signIn[\/]*$`)
-	signupRe = regexp.MustCompile(`^\/api\/auth\/signUp[\/]*$`)
+const (
+	refreshTokenCookie = "RefreshToken"
+	refreshTokenPath   = "/api/auth"
+	deviceIdHeader     = "X-Device-ID"
 )
 
 type AuthHandler struct {
-	log *slog.Logger
-	uc  auth.AuthUsecase
+	uc auth.AuthUsecase
+	// secureCookies is off only for local development over plain http
+	secureCookies bool
 }
 
-func NewAuthHandler(log *slog.Logger, uc auth.AuthUsecase) AuthHandler {
+func NewAuthHandler(uc auth.AuthUsecase, secureCookies bool) AuthHandler {
 	return AuthHandler{
-		log: log,
-		uc:  uc,
-	}
-}
-
-func (ah *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("content-type", "application/json")
-	fmt.Println(r.URL.Path)
-	switch {
-	case r.Method == http.MethodPost && signinRe.MatchString(r.URL.Path):
-		ah.SignIn(w, r)
-		return
-	case r.Method == http.MethodPost && signupRe.MatchString(r.URL.Path):
-		ah.SignUp(w, r)
-		return
-	default:
-		resp.JSONStatus(w, http.StatusNotFound)
+		uc:            uc,
+		secureCookies: secureCookies,
 	}
 }
 
 // SignIn godoc
 // @Summary      User sign-in
-// @Description  Authenticates a user and generates an access token
+// @Description  Authenticates a user and generates an access token. Accounts with two-factor authentication get a challenge instead, completed at /api/auth/2fa/verify
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
-// @Param        user  body  models.User  true  "User information"
-// @Success      200
-// @Failure      400
-// @Failure      500
-// @Router       /api/signIn [post]
+// @Param        user  body  models.Credentials  true  "Login and password"
+// @Success      200  {object}  models.TokenPair
+// @Success      200  {object}  models.MFAChallenge
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      429  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/signIn [post]
 func (ah *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 
 	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
+		badRequest(w)
 		return
 	}
 	defer r.Body.Close()
 
-	u := &models.User{}
-	err = json.Unmarshal(body, u)
+	creds := &models.Credentials{}
+	err = json.Unmarshal(body, creds)
 	if err != nil {
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		badRequest(w)
 		return
 	}
 
-	err = ah.uc.SignIn(r.Context(), u)
+	u := &models.User{Login: creds.Login, Password: creds.Password}
+
+	challenge, err := ah.uc.SignIn(r.Context(), u, clientIP(r))
 	if err != nil {
-		fmt.Println(err)
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	if challenge != nil {
+		resp.JSON(w, http.StatusOK, challenge)
 		return
 	}
 
-	token, err := jwt.TokenManagerSingletone.NewJWT(u.Id, u.IsAdmin)
+	tokens, err := ah.uc.StartSession(r.Context(), u, device(r))
 	if err != nil {
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{Name: "AccessToken", Value: "Bearer " + token})
-	resp.JSONStatus(w, http.StatusOK)
+	ah.setTokenCookies(w, tokens)
+	resp.JSON(w, http.StatusOK, tokens)
 }
 
 // SignUp godoc
 // @Summary      Sign up a new user
-// @Description  Creates a new user account
+// @Description  Creates a new client account. The login is 3 to 16 latin letters, digits, '_', '.' or '-'; the password must satisfy the configured strength rules
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
-// @Param        user  body  models.User  true  "User information"
-// @Success      200
-// @Failure      400
-// @Failure      500
-// @Router       /api/signUp [post]
+// @Param        user  body  models.Credentials  true  "Login and password"
+// @Success      200  {object}  models.TokenPair
+// @Failure      400  {object}  resp.Problem
+// @Failure      422  {object}  resp.Problem
+// @Failure      409  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/signUp [post]
 func (ah *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("signup")
 	body, err := io.ReadAll(r.Body)
 
 	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
+		badRequest(w)
 		return
 	}
 	defer r.Body.Close()
 
-	u := &models.User{}
-	err = json.Unmarshal(body, u)
+	creds := &models.Credentials{}
+	err = json.Unmarshal(body, creds)
 	if err != nil {
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		badRequest(w)
 		return
 	}
 
+	u := &models.User{Login: creds.Login, Password: creds.Password}
+
 	u.Id, err = ah.uc.SignUp(r.Context(), u)
 	if err != nil {
-		fmt.Println(err)
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	tokens, err := ah.uc.StartSession(r.Context(), u, device(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ah.setTokenCookies(w, tokens)
+	resp.JSON(w, http.StatusOK, tokens)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Refresh godoc
+// @Summary      Refresh tokens
+// @Description  Exchanges a refresh token for a new access/refresh pair. The refresh token is taken from the body or the RefreshToken cookie and can be used only once
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        token  body  refreshRequest  false  "Refresh token"
+// @Param        X-Device-ID  header  string  false  "Device the session is bound to, User-Agent by default"
+// @Success      200  {object}  models.TokenPair
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/refresh [post]
+func (ah *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w)
+		return
+	}
+	defer r.Body.Close()
+
+	req := refreshRequest{}
+	if len(body) != 0 {
+		if err = json.Unmarshal(body, &req); err != nil {
+			badRequest(w)
+			return
+		}
+	}
+
+	if req.RefreshToken == "" {
+		if cookie, err := r.Cookie(refreshTokenCookie); err == nil {
+			req.RefreshToken = cookie.Value
+		}
+	}
+
+	if req.RefreshToken == "" {
+		resp.Fail(w, http.StatusUnauthorized, auth.ErrInvalidRefreshToken.Error())
+		return
+	}
+
+	tokens, err := ah.uc.Refresh(r.Context(), req.RefreshToken, device(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ah.setTokenCookies(w, tokens)
+	resp.JSON(w, http.StatusOK, tokens)
+}
+
+// Logout godoc
+// @Summary      Log out
+// @Description  Ends the current session and revokes its access token
+// @Tags         Authentication
+// @Security     BearerAuth
+// @Success      200
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/logout [post]
+func (ah *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		resp.Fail(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	err := ah.uc.Logout(r.Context(), claims)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ah.clearTokenCookies(w)
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+// LogoutAll godoc
+// @Summary      Log out everywhere
+// @Description  Ends all sessions of the current user and revokes their access tokens
+// @Tags         Authentication
+// @Security     BearerAuth
+// @Success      200
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/logout-all [post]
+func (ah *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		resp.Fail(w, http.StatusUnauthorized, "authentication required")
 		return
 	}
 
-	token, err := jwt.TokenManagerSingletone.NewJWT(u.Id, u.IsAdmin)
+	err := ah.uc.LogoutAll(r.Context(), claims)
 	if err != nil {
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{Name: "AccessToken", Value: "Bearer " + token})
+	ah.clearTokenCookies(w)
 	resp.JSONStatus(w, http.StatusOK)
 }
+
+type resetPasswordRequest struct {
+	ResetToken string `json:"resetToken"`
+	Password   string `json:"password"`
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Sets a new password with the one-time token issued by an admin
+// @Tags         Authentication
+// @Accept       json
+// @Param        reset  body  resetPasswordRequest  true  "Reset token and new password"
+// @Success      200
+// @Failure      400  {object}  resp.Problem
+// @Failure      422  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/password/reset [post]
+func (ah *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w)
+		return
+	}
+	defer r.Body.Close()
+
+	req := resetPasswordRequest{}
+	if err = json.Unmarshal(body, &req); err != nil || req.ResetToken == "" || req.Password == "" {
+		badRequest(w)
+		return
+	}
+
+	err = ah.uc.ResetPassword(r.Context(), req.ResetToken, req.Password)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+// GetMe godoc
+// @Summary      Current user
+// @Description  Returns the account the access token belongs to
+// @Tags         Authentication
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.User
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/me [get]
+func (ah *AuthHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		resp.Fail(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	user, err := ah.uc.GetUser(r.Context(), claims.UserId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, user)
+}
+
+type changePasswordRequest struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+// ChangePassword godoc
+// @Summary      Change password
+// @Description  Changes the password of the current user and ends all other sessions. Wrong old passwords count towards the sign-in lockout, answered with 429 and Retry-After. Accounts created through SSO have no old password, they set a first one within 5 minutes of signing in through SSO
+// @Tags         Authentication
+// @Accept       json
+// @Param        passwords  body  changePasswordRequest  true  "Old and new password"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      400  {object}  resp.Problem
+// @Failure      422  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      429  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/password [post]
+func (ah *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		resp.Fail(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w)
+		return
+	}
+	defer r.Body.Close()
+
+	req := changePasswordRequest{}
+	if err = json.Unmarshal(body, &req); err != nil || req.NewPassword == "" {
+		badRequest(w)
+		return
+	}
+
+	err = ah.uc.ChangePassword(r.Context(), claims, req.OldPassword, req.NewPassword)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		resp.Fail(w, http.StatusForbidden, "old password is wrong")
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// DeleteMe godoc
+// @Summary      Delete account
+// @Description  Deletes the account of the current user. The password is asked again to confirm, wrong passwords count towards the sign-in lockout. Accounts without a password confirm by signing in through SSO less than 5 minutes before
+// @Tags         Authentication
+// @Accept       json
+// @Param        password  body  deleteAccountRequest  true  "Current password"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      429  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/me [delete]
+func (ah *AuthHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		resp.Fail(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w)
+		return
+	}
+	defer r.Body.Close()
+
+	req := deleteAccountRequest{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		badRequest(w)
+		return
+	}
+
+	err = ah.uc.DeleteAccount(r.Context(), claims, req.Password)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		resp.Fail(w, http.StatusForbidden, "password is wrong")
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ah.clearTokenCookies(w)
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+// Browsers get the tokens as cookies; other clients should use the response
+// body and send the access token in the Authorization header.
+func (ah *AuthHandler) setTokenCookies(w http.ResponseWriter, tokens *models.TokenPair) {
+	setTokenCookies(w, tokens, ah.secureCookies)
+}
+
+func setTokenCookies(w http.ResponseWriter, tokens *models.TokenPair, secure bool) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.AccessTokenCookie,
+		Value:    tokens.AccessToken,
+		Path:     "/",
+		Expires:  tokens.ExpiresAt,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    tokens.RefreshToken,
+		Path:     refreshTokenPath,
+		Expires:  tokens.RefreshExpiresAt,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func (ah *AuthHandler) clearTokenCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.AccessTokenCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   ah.secureCookies,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Path:     refreshTokenPath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   ah.secureCookies,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// device identifies the client a session is bound to.
+func device(r *http.Request) string {
+	if id := r.Header.Get(deviceIdHeader); id != "" {
+		return id
+	}
+
+	return r.UserAgent()
+}
+
+// clientIP is the address sign-in attempts are counted against. Forwarded
+// headers are not trusted, they can be set by anyone.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"MovieService/internal/pkg/utils/jwt"
+	resp "MovieService/internal/pkg/utils/responser"
+	"net/http"
+)
+
+// KeysHandler publishes the public keys access tokens are signed with, so
+// other services can verify the tokens without a shared secret.
+type KeysHandler struct {
+	tm jwt.TokenManager
+}
+
+func NewKeysHandler(tm jwt.TokenManager) KeysHandler {
+	return KeysHandler{
+		tm: tm,
+	}
+}
+
+// GetKeys godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys of the RS256 and EdDSA keys in the keyring, looked up by the kid header of a token
+// @Tags         Authentication
+// @Produce      json
+// @Success      200  {object}  jwt.JWKSet
+// @Router       /.well-known/jwks.json [get]
+func (kh *KeysHandler) GetKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	resp.JSON(w, http.StatusOK, kh.tm.JWKS())
+}
@@ -0,0 +1,213 @@
+package http
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	resp "MovieService/internal/pkg/utils/responser"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+type totpCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// VerifyMFA godoc
+// @Summary      Complete sign-in with a second factor
+// @Description  Exchanges the challenge returned by sign-in and an authenticator or recovery code for the tokens
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        verification  body  models.MFAVerification  true  "Challenge token and code"
+// @Success      200  {object}  models.TokenPair
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      429  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/2fa/verify [post]
+func (ah *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w)
+		return
+	}
+	defer r.Body.Close()
+
+	v := &models.MFAVerification{}
+	if err = json.Unmarshal(body, v); err != nil || v.ChallengeToken == "" || (v.Code == "") == (v.RecoveryCode == "") {
+		resp.Fail(w, http.StatusBadRequest, "challengeToken and either code or recoveryCode are required")
+		return
+	}
+
+	tokens, err := ah.uc.CompleteSignIn(r.Context(), v, device(r), clientIP(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ah.setTokenCookies(w, tokens)
+	resp.JSON(w, http.StatusOK, tokens)
+}
+
+// EnrollTOTP godoc
+// @Summary      Enroll authenticator
+// @Description  Generates a TOTP secret and its otpauth:// URI. Two-factor authentication is enabled once a code is confirmed
+// @Tags         Two-factor authentication
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.TOTPEnrollment
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      409  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/2fa/enroll [post]
+func (ah *AuthHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, err := ah.uc.EnrollTOTP(r.Context(), claims)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP godoc
+// @Summary      Confirm authenticator
+// @Description  Enables two-factor authentication with the first code of the enrolled authenticator. The recovery codes are returned only once. Wrong codes count towards the sign-in lockout, answered with 429 and Retry-After
+// @Tags         Two-factor authentication
+// @Accept       json
+// @Produce      json
+// @Param        code  body  totpCodeRequest  true  "Code of the authenticator"
+// @Security     BearerAuth
+// @Success      200  {object}  models.RecoveryCodes
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      409  {object}  resp.Problem
+// @Failure      429  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/2fa/confirm [post]
+func (ah *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+
+	code, ok := readTOTPCode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := ah.uc.ConfirmTOTP(r.Context(), claims, code)
+	if err != nil {
+		writeCodeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, codes)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Replaces all recovery codes. A current authenticator code is required. Wrong codes count towards the sign-in lockout, answered with 429 and Retry-After
+// @Tags         Two-factor authentication
+// @Accept       json
+// @Produce      json
+// @Param        code  body  totpCodeRequest  true  "Code of the authenticator"
+// @Security     BearerAuth
+// @Success      200  {object}  models.RecoveryCodes
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      429  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/2fa/recovery-codes [post]
+func (ah *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+
+	code, ok := readTOTPCode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := ah.uc.RegenerateRecoveryCodes(r.Context(), claims, code)
+	if err != nil {
+		writeCodeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, codes)
+}
+
+// DisableTOTP godoc
+// @Summary      Disable two-factor authentication
+// @Description  Removes the authenticator and the recovery codes. A current authenticator code is required. Wrong codes count towards the sign-in lockout, answered with 429 and Retry-After
+// @Tags         Two-factor authentication
+// @Accept       json
+// @Param        code  body  totpCodeRequest  true  "Code of the authenticator"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      429  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/2fa [delete]
+func (ah *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+
+	code, ok := readTOTPCode(w, r)
+	if !ok {
+		return
+	}
+
+	err := ah.uc.DisableTOTP(r.Context(), claims, code)
+	if err != nil {
+		writeCodeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+// writeCodeError answers a wrong code of a signed-in user with 403 rather
+// than 401, the access token itself is fine.
+func writeCodeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, auth.ErrInvalidOTP) {
+		resp.WriteProblem(w, resp.NewProblem(http.StatusForbidden, auth.ErrInvalidOTP.Code, err.Error()))
+		return
+	}
+
+	writeError(w, r, err)
+}
+
+func readTOTPCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w)
+		return "", false
+	}
+	defer r.Body.Close()
+
+	req := totpCodeRequest{}
+	if err = json.Unmarshal(body, &req); err != nil || req.Code == "" {
+		resp.Fail(w, http.StatusBadRequest, "code is required")
+		return "", false
+	}
+
+	return req.Code, true
+}
@@ -0,0 +1,201 @@
+package http
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/utils/logger"
+	resp "MovieService/internal/pkg/utils/responser"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+const (
+	oidcStateCookie = "OIDCState"
+	oidcPath        = "/api/auth/oidc"
+	// matches the lifetime of the pending request on the server
+	oidcStateMaxAge = 10 * 60
+)
+
+type OIDCHandler struct {
+	uc auth.OIDCUsecase
+	// afterLogin is where the browser is sent once signed in; the tokens are
+	// returned as JSON when it is empty
+	afterLogin    string
+	secureCookies bool
+}
+
+func NewOIDCHandler(uc auth.OIDCUsecase, afterLogin string, secureCookies bool) OIDCHandler {
+	return OIDCHandler{
+		uc:            uc,
+		afterLogin:    afterLogin,
+		secureCookies: secureCookies,
+	}
+}
+
+// Login godoc
+// @Summary      Sign in with SSO
+// @Description  Redirects the browser to the OpenID Connect provider
+// @Tags         Authentication
+// @Success      302
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/oidc/login [get]
+func (oh *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if !oh.configured(w) {
+		return
+	}
+
+	authURL, state, err := oh.uc.Begin(r.Context(), 0)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("failed to start OIDC login", "error", err)
+		writeError(w, r, err)
+		return
+	}
+
+	oh.setStateCookie(w, state)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Link godoc
+// @Summary      Link SSO identity
+// @Description  Starts a login at the OpenID Connect provider that links the identity to the current account. The browser has to be sent to the returned URL
+// @Tags         Authentication
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.OIDCLogin
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/oidc/link [post]
+func (oh *OIDCHandler) Link(w http.ResponseWriter, r *http.Request) {
+	if !oh.configured(w) {
+		return
+	}
+
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+
+	authURL, state, err := oh.uc.Begin(r.Context(), claims.UserId)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("failed to start OIDC link", "error", err)
+		writeError(w, r, err)
+		return
+	}
+
+	oh.setStateCookie(w, state)
+	resp.JSON(w, http.StatusOK, models.OIDCLogin{AuthorizationURL: authURL})
+}
+
+// Callback godoc
+// @Summary      SSO callback
+// @Description  Completes the login at the OpenID Connect provider. Redirects to the configured page or returns the tokens. Accounts with two-factor authentication get a challenge instead, completed at /api/auth/2fa/verify: it is returned as JSON or passed to the page as the mfa_challenge fragment parameter
+// @Tags         Authentication
+// @Produce      json
+// @Param        code   query  string  true  "Authorization code"
+// @Param        state  query  string  true  "State of the request"
+// @Success      200  {object}  models.TokenPair
+// @Success      200  {object}  models.MFAChallenge
+// @Success      302
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      409  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/auth/oidc/callback [get]
+func (oh *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	if !oh.configured(w) {
+		return
+	}
+
+	query := r.URL.Query()
+	state := query.Get("state")
+	oh.clearStateCookie(w)
+
+	if idpErr := query.Get("error"); idpErr != "" {
+		logger.FromContext(r.Context()).Info("OIDC login refused by provider", "error", idpErr, "description", query.Get("error_description"))
+		resp.Fail(w, http.StatusUnauthorized, "sign-in refused by the identity provider: "+idpErr)
+		return
+	}
+
+	// the state has to come back to the browser that started the login
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		resp.Fail(w, http.StatusBadRequest, auth.ErrInvalidOIDCState.Error())
+		return
+	}
+
+	tokens, challenge, err := oh.uc.Complete(r.Context(), state, query.Get("code"), device(r))
+	if errors.Is(err, auth.ErrInvalidOIDCState) {
+		resp.Fail(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil && !errors.Is(err, auth.ErrUserDisabled) && !errors.Is(err, auth.ErrIdentityLinked) {
+		logger.FromContext(r.Context()).Info("OIDC login failed", "error", err)
+		resp.Fail(w, http.StatusUnauthorized, "single sign-on failed")
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// the second factor is asked by the page; the challenge token goes in the
+	// fragment, which browsers never send to a server
+	if challenge != nil {
+		if oh.afterLogin != "" {
+			fragment := url.Values{"mfa_challenge": {challenge.ChallengeToken}}
+			http.Redirect(w, r, oh.afterLogin+"#"+fragment.Encode(), http.StatusFound)
+			return
+		}
+
+		resp.JSON(w, http.StatusOK, challenge)
+		return
+	}
+
+	setTokenCookies(w, tokens, oh.secureCookies)
+	if oh.afterLogin != "" {
+		http.Redirect(w, r, oh.afterLogin, http.StatusFound)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, tokens)
+}
+
+func (oh *OIDCHandler) configured(w http.ResponseWriter) bool {
+	if oh.uc == nil {
+		resp.Fail(w, http.StatusNotFound, "single sign-on is not configured")
+		return false
+	}
+
+	return true
+}
+
+// The state cookie is SameSite=Lax: the callback is a cross-site navigation
+// from the provider, Strict cookies would not be sent with it.
+func (oh *OIDCHandler) setStateCookie(w http.ResponseWriter, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     oidcPath,
+		MaxAge:   oidcStateMaxAge,
+		HttpOnly: true,
+		Secure:   oh.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (oh *OIDCHandler) clearStateCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     oidcPath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   oh.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
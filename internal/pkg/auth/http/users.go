@@ -0,0 +1,338 @@
+package http
+
+import (
+	// the models are only referenced by the swagger annotations
+	_ "MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/middleware"
+	resp "MovieService/internal/pkg/utils/responser"
+	"MovieService/internal/pkg/utils/router"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type UsersHandler struct {
+	uc auth.AuthUsecase
+}
+
+func NewUsersHandler(uc auth.AuthUsecase) UsersHandler {
+	return UsersHandler{
+		uc: uc,
+	}
+}
+
+// GetUsers godoc
+// @Summary      Get list of users
+// @Description  Retrieves a page of users ordered by id
+// @Tags         Users
+// @Produce      json
+// @Param        page   query  int  false  "Page number, starting from 1"
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Security     BearerAuth
+// @Success      200  {object}  models.UserPage
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users [get]
+func (uh *UsersHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	users, err := uh.uc.ListUsers(r.Context(), page, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, users)
+}
+
+// GetUser godoc
+// @Summary      Get user by ID
+// @Tags         Users
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.User
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/{id} [get]
+func (uh *UsersHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id := router.Int(r, "id")
+
+	user, err := uh.uc.GetUser(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, user)
+}
+
+// DeleteUser godoc
+// @Summary      Delete user by ID
+// @Description  Deletes the account and ends all of its sessions
+// @Tags         Users
+// @Param        id  path  int  true  "User ID"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      409  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/{id} [delete]
+func (uh *UsersHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := uh.otherUserId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := uh.uc.DeleteUser(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+// GrantAdmin godoc
+// @Summary      Grant admin role
+// @Tags         Users
+// @Param        id  path  int  true  "User ID"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/{id}/admin [put]
+func (uh *UsersHandler) GrantAdmin(w http.ResponseWriter, r *http.Request) {
+	id := router.Int(r, "id")
+
+	if err := uh.uc.SetAdmin(r.Context(), id, true); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+// RevokeAdmin godoc
+// @Summary      Revoke admin role
+// @Description  Revokes the admin role and ends all sessions of the user
+// @Tags         Users
+// @Param        id  path  int  true  "User ID"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      409  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/{id}/admin [delete]
+func (uh *UsersHandler) RevokeAdmin(w http.ResponseWriter, r *http.Request) {
+	id, ok := uh.otherUserId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := uh.uc.SetAdmin(r.Context(), id, false); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+// DisableUser godoc
+// @Summary      Disable user
+// @Description  Disables the account and ends all of its sessions
+// @Tags         Users
+// @Param        id  path  int  true  "User ID"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      409  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/{id}/disabled [put]
+func (uh *UsersHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := uh.otherUserId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := uh.uc.SetDisabled(r.Context(), id, true); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+// EnableUser godoc
+// @Summary      Enable user
+// @Tags         Users
+// @Param        id  path  int  true  "User ID"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/{id}/disabled [delete]
+func (uh *UsersHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	id := router.Int(r, "id")
+
+	if err := uh.uc.SetDisabled(r.Context(), id, false); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+// ForcePasswordReset godoc
+// @Summary      Force password reset
+// @Description  Invalidates the password and sessions of the user and returns a one-time reset token to hand over to the user
+// @Tags         Users
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.PasswordReset
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/{id}/password-reset [post]
+func (uh *UsersHandler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	id := router.Int(r, "id")
+
+	reset, err := uh.uc.ForcePasswordReset(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, reset)
+}
+
+// otherUserId returns the user id from the path and refuses requests an admin
+// makes against their own account, so the last admin cannot lock themselves out.
+func (uh *UsersHandler) otherUserId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id := router.Int(r, "id")
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if ok && claims.UserId == id {
+		resp.Fail(w, http.StatusConflict, "the action cannot be applied to your own account")
+		return 0, false
+	}
+
+	return id, true
+}
+
+// GetLockouts godoc
+// @Summary      Get locked sign-ins
+// @Description  Lists the logins ("login:<login>") and client addresses ("ip:<address>") that are currently locked out
+// @Tags         Users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}  models.LoginAttempts
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/lockouts [get]
+func (uh *UsersHandler) GetLockouts(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := uh.uc.ListLockouts(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, lockouts)
+}
+
+// Unlock godoc
+// @Summary      Unlock sign-in
+// @Description  Clears the failed attempts of a login or a client address
+// @Tags         Users
+// @Param        key  path  string  true  "Lockout key, e.g. login:alice or ip:10.0.0.1"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/lockouts/{key} [delete]
+func (uh *UsersHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	err := uh.uc.Unlock(r.Context(), key)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+type pruneResult struct {
+	Pruned int64 `json:"pruned"`
+}
+
+// PruneApiKeys godoc
+// @Summary      Prune stale API keys
+// @Description  Deletes revoked and expired API keys of all users and those not used for the given number of days
+// @Tags         Users
+// @Produce      json
+// @Param        unusedDays  query  int  true  "Days since the last use (or creation, if never used)"
+// @Security     BearerAuth
+// @Success      200  {object}  pruneResult
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/api-keys/stale [delete]
+func (uh *UsersHandler) PruneApiKeys(w http.ResponseWriter, r *http.Request) {
+	days, err := strconv.Atoi(r.URL.Query().Get("unusedDays"))
+	if err != nil || days < 1 {
+		resp.Fail(w, http.StatusBadRequest, "unusedDays must be a positive number")
+		return
+	}
+
+	pruned, err := uh.uc.PruneApiKeys(r.Context(), time.Duration(days)*24*time.Hour)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, pruneResult{Pruned: pruned})
+}
+
+// ResetTOTP godoc
+// @Summary      Reset two-factor authentication
+// @Description  Removes the authenticator and recovery codes of a user who lost them
+// @Tags         Users
+// @Param        id  path  int  true  "User ID"
+// @Security     BearerAuth
+// @Success      200
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/users/{id}/2fa [delete]
+func (uh *UsersHandler) ResetTOTP(w http.ResponseWriter, r *http.Request) {
+	id := router.Int(r, "id")
+
+	err := uh.uc.ResetTOTP(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp.JSONStatus(w, http.StatusOK)
+}
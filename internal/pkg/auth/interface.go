@@ -3,14 +3,107 @@ package auth
 import (
 	"MovieService/internal/models"
 	"context"
+	"time"
 )
 
 type AuthRepo interface {
 	CreateUser(context.Context, *models.User) (int, error)
 	GetUserByLogin(context.Context, string) (*models.User, error)
+	GetUserById(context.Context, int) (*models.User, error)
+	UpdatePassword(context.Context, int, string) error
+	GetLegacyPasswords(context.Context) (map[int]string, error)
+	MigratePassword(context.Context, int, string, string) error
+	CreateSession(context.Context, *models.Session) (int, error)
+	GetSession(context.Context, int) (*models.Session, error)
+	RevokeSession(context.Context, int) error
+	RevokeUserSessions(context.Context, int) error
+	RevokeOtherSessions(context.Context, int, int) error
+	SetSessionAccessToken(context.Context, int, string, time.Time) error
+	CreateRefreshToken(context.Context, *models.RefreshToken) error
+	GetRefreshToken(context.Context, string) (*models.RefreshToken, error)
+	UseRefreshToken(context.Context, int) (bool, error)
+	RevokeToken(context.Context, string, time.Time) error
+	GetTokenStatus(context.Context, string, int, int) (bool, bool, error)
+	ListUsers(context.Context, int, int) ([]models.User, int, error)
+	SetAdmin(context.Context, int, bool) error
+	SetDisabled(context.Context, int, bool) error
+	DeleteUser(context.Context, int) error
+	SetPasswordReset(context.Context, int, string, string, time.Time) error
+	ResetPassword(context.Context, string, string) (int, error)
+	CreateApiKey(context.Context, *models.ApiKey, string) (int, error)
+	ListApiKeys(context.Context, int) ([]models.ApiKey, error)
+	GetApiKeyByHash(context.Context, string) (*models.ApiKey, error)
+	RevokeApiKey(context.Context, int, int) error
+	TouchApiKey(context.Context, int) error
+	PruneApiKeys(context.Context, time.Time) (int64, error)
+	CreateOIDCState(context.Context, string, *models.OIDCState, time.Time) error
+	TakeOIDCState(context.Context, string) (*models.OIDCState, error)
+	GetUserByIdentity(context.Context, string, string) (*models.User, error)
+	LinkIdentity(context.Context, int, string, string) error
+	GetTOTP(context.Context, int) (*models.TOTP, error)
+	SetTOTPSecret(context.Context, int, string) error
+	EnableTOTP(context.Context, int, int64, []string) error
+	UseTOTPStep(context.Context, int, int64) (bool, error)
+	DeleteTOTP(context.Context, int) error
+	ReplaceRecoveryCodes(context.Context, int, []string) error
+	UseRecoveryCode(context.Context, int, string) (bool, error)
+	CreateMFAChallenge(context.Context, string, int, time.Time) error
+	AttemptMFAChallenge(context.Context, string, int) (int, error)
+	DeleteMFAChallenge(context.Context, string) error
+}
+
+// AttemptStore keeps the failed sign-in counters. Keys that have had no
+// failures for longer than the window start from zero again.
+type AttemptStore interface {
+	Get(context.Context, string) (*models.LoginAttempts, error)
+	AddFailure(context.Context, string, time.Duration) (*models.LoginAttempts, error)
+	Lock(context.Context, string, time.Time) error
+	Reset(context.Context, string) error
+	ListLocked(context.Context) ([]models.LoginAttempts, error)
+}
+
+// OIDCProvider is an OpenID Connect issuer users can sign in with.
+type OIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error)
+	Exchange(ctx context.Context, code string, verifier string, nonce string) (*models.ExternalIdentity, error)
 }
 
 type AuthUsecase interface {
-	SignIn(context.Context, *models.User) error
+	SignIn(context.Context, *models.User, string) (*models.MFAChallenge, error)
+	CompleteSignIn(context.Context, *models.MFAVerification, string, string) (*models.TokenPair, error)
 	SignUp(context.Context, *models.User) (int, error)
+	ChallengeMFA(context.Context, *models.User) (*models.MFAChallenge, error)
+	StartSession(context.Context, *models.User, string) (*models.TokenPair, error)
+	StartSSOSession(context.Context, *models.User, string, time.Time) (*models.TokenPair, error)
+	Refresh(context.Context, string, string) (*models.TokenPair, error)
+	Logout(context.Context, *models.JwtClaims) error
+	LogoutAll(context.Context, *models.JwtClaims) error
+	CheckToken(context.Context, *models.JwtClaims) error
+	ResetPassword(context.Context, string, string) error
+	MigrateLegacyPasswords(context.Context) (int, error)
+	ChangePassword(context.Context, *models.JwtClaims, string, string) error
+	DeleteAccount(context.Context, *models.JwtClaims, string) error
+	ListUsers(context.Context, int, int) (*models.UserPage, error)
+	GetUser(context.Context, int) (*models.User, error)
+	SetAdmin(context.Context, int, bool) error
+	SetDisabled(context.Context, int, bool) error
+	ForcePasswordReset(context.Context, int) (*models.PasswordReset, error)
+	DeleteUser(context.Context, int) error
+	ListLockouts(context.Context) ([]models.LoginAttempts, error)
+	Unlock(context.Context, string) error
+	CreateApiKey(context.Context, *models.JwtClaims, *models.ApiKey) (*models.NewApiKey, error)
+	ListApiKeys(context.Context, *models.JwtClaims) ([]models.ApiKey, error)
+	RevokeApiKey(context.Context, *models.JwtClaims, int) error
+	CheckApiKey(context.Context, string) (*models.JwtClaims, error)
+	PruneApiKeys(context.Context, time.Duration) (int64, error)
+	EnrollTOTP(context.Context, *models.JwtClaims) (*models.TOTPEnrollment, error)
+	ConfirmTOTP(context.Context, *models.JwtClaims, string) (*models.RecoveryCodes, error)
+	DisableTOTP(context.Context, *models.JwtClaims, string) error
+	RegenerateRecoveryCodes(context.Context, *models.JwtClaims, string) (*models.RecoveryCodes, error)
+	ResetTOTP(context.Context, int) error
+}
+
+type OIDCUsecase interface {
+	Begin(context.Context, int) (string, string, error)
+	Complete(context.Context, string, string, string) (*models.TokenPair, *models.MFAChallenge, error)
 }
@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+const (
+	createApiKey = `INSERT INTO api_key (user_id, name, prefix, key_hash, permissions, expires_at) ` +
+		`VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at;`
+	listApiKeys = `SELECT id, user_id, name, prefix, permissions, created_at, expires_at, last_used_at, revoked_at ` +
+		`FROM api_key WHERE user_id=$1 ORDER BY id;`
+	getApiKeyByHash = `SELECT id, user_id, name, prefix, permissions, created_at, expires_at, last_used_at, revoked_at ` +
+		`FROM api_key WHERE key_hash=$1;`
+	revokeApiKey = `UPDATE api_key SET revoked_at=now() WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL;`
+	// the timestamp is written at most once a minute to spare the hot path
+	touchApiKey = `UPDATE api_key SET last_used_at=now() ` +
+		`WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');`
+	pruneApiKeys = `DELETE FROM api_key WHERE revoked_at IS NOT NULL OR expires_at <= now() ` +
+		`OR COALESCE(last_used_at, created_at) < $1;`
+)
+
+func (ar *AuthRepo) CreateApiKey(ctx context.Context, key *models.ApiKey, keyHash string) (int, error) {
+	err := ar.db.QueryRow(ctx, createApiKey,
+		key.UserId, key.Name, key.Prefix, keyHash, key.Permissions, key.ExpiresAt).Scan(&key.Id, &key.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("error happened in scan.Scan: %w", err)
+
+		return 0, err
+	}
+
+	return key.Id, nil
+}
+
+func (ar *AuthRepo) ListApiKeys(ctx context.Context, userId int) ([]models.ApiKey, error) {
+	rows, err := ar.db.Query(ctx, listApiKeys, userId)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Query: %w", err)
+
+		return []models.ApiKey{}, err
+	}
+	defer rows.Close()
+
+	keySlice := make([]models.ApiKey, 0)
+	for rows.Next() {
+		key := models.ApiKey{}
+		err = rows.Scan(&key.Id, &key.UserId, &key.Name, &key.Prefix, &key.Permissions,
+			&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+		if err != nil {
+			err = fmt.Errorf("error happened in rows.Scan: %w", err)
+
+			return []models.ApiKey{}, err
+		}
+
+		keySlice = append(keySlice, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error happened in rows.Next: %w", err)
+
+		return []models.ApiKey{}, err
+	}
+
+	return keySlice, nil
+}
+
+func (ar *AuthRepo) GetApiKeyByHash(ctx context.Context, keyHash string) (*models.ApiKey, error) {
+	key := &models.ApiKey{}
+	err := ar.db.QueryRow(ctx, getApiKeyByHash, keyHash).Scan(&key.Id, &key.UserId, &key.Name, &key.Prefix,
+		&key.Permissions, &key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.ApiKey{}, auth.ErrInvalidApiKey
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return &models.ApiKey{}, err
+	}
+
+	return key, nil
+}
+
+func (ar *AuthRepo) RevokeApiKey(ctx context.Context, userId int, id int) error {
+	tag, err := ar.db.Exec(ctx, revokeApiKey, id, userId)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrApiKeyNotFound
+	}
+
+	return nil
+}
+
+func (ar *AuthRepo) TouchApiKey(ctx context.Context, id int) error {
+	_, err := ar.db.Exec(ctx, touchApiKey, id)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ar *AuthRepo) PruneApiKeys(ctx context.Context, unusedSince time.Time) (int64, error) {
+	tag, err := ar.db.Exec(ctx, pruneApiKeys, unusedSince)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
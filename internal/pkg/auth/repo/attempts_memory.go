@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"MovieService/internal/models"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryAttemptStore keeps the sign-in counters in process memory. It is
+// enough for a single instance; counters are lost on restart.
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]*models.LoginAttempts
+	window   time.Duration
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		attempts: make(map[string]*models.LoginAttempts),
+	}
+}
+
+func (ms *MemoryAttemptStore) Get(_ context.Context, key string) (*models.LoginAttempts, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	a, ok := ms.attempts[key]
+	if !ok || ms.stale(a, time.Now()) {
+		return &models.LoginAttempts{Key: key}, nil
+	}
+
+	result := *a
+	return &result, nil
+}
+
+func (ms *MemoryAttemptStore) AddFailure(_ context.Context, key string, window time.Duration) (*models.LoginAttempts, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	ms.window = window
+	ms.purge(now)
+
+	a, ok := ms.attempts[key]
+	if !ok {
+		a = &models.LoginAttempts{Key: key}
+		ms.attempts[key] = a
+	}
+
+	a.Failures++
+	a.LastFailure = now
+
+	result := *a
+	return &result, nil
+}
+
+func (ms *MemoryAttemptStore) Lock(_ context.Context, key string, until time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if a, ok := ms.attempts[key]; ok {
+		a.LockedUntil = &until
+	}
+
+	return nil
+}
+
+func (ms *MemoryAttemptStore) Reset(_ context.Context, key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.attempts, key)
+	return nil
+}
+
+func (ms *MemoryAttemptStore) ListLocked(_ context.Context) ([]models.LoginAttempts, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	locked := make([]models.LoginAttempts, 0)
+	for _, a := range ms.attempts {
+		if a.LockedUntil != nil && a.LockedUntil.After(now) {
+			locked = append(locked, *a)
+		}
+	}
+
+	sort.Slice(locked, func(i, j int) bool {
+		return locked[i].Key < locked[j].Key
+	})
+
+	return locked, nil
+}
+
+func (ms *MemoryAttemptStore) stale(a *models.LoginAttempts, now time.Time) bool {
+	if a.LockedUntil != nil && a.LockedUntil.After(now) {
+		return false
+	}
+
+	return ms.window > 0 && now.Sub(a.LastFailure) > ms.window
+}
+
+// purge drops the counters that are out of the window, so the map does not
+// grow with every address that ever failed once.
+func (ms *MemoryAttemptStore) purge(now time.Time) {
+	for key, a := range ms.attempts {
+		if ms.stale(a, now) {
+			delete(ms.attempts, key)
+		}
+	}
+}
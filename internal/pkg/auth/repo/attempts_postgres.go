@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"MovieService/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+const (
+	getAttempts = `SELECT key, failures, last_failure, locked_until FROM login_attempt WHERE key=$1;`
+	addFailure  = `INSERT INTO login_attempt (key, failures, last_failure) VALUES ($1, 1, now()) ` +
+		`ON CONFLICT (key) DO UPDATE SET ` +
+		`failures = CASE WHEN login_attempt.last_failure <= now() - $2::interval ` +
+		`AND COALESCE(login_attempt.locked_until <= now(), true) THEN 1 ELSE login_attempt.failures + 1 END, ` +
+		`last_failure = now() ` +
+		`RETURNING key, failures, last_failure, locked_until;`
+	lockAttempts  = `UPDATE login_attempt SET locked_until=$1 WHERE key=$2;`
+	resetAttempts = `DELETE FROM login_attempt WHERE key=$1;`
+	listLocked    = `SELECT key, failures, last_failure, locked_until FROM login_attempt ` +
+		`WHERE locked_until > now() ORDER BY key;`
+	purgeAttempts = `DELETE FROM login_attempt ` +
+		`WHERE last_failure <= now() - $1::interval AND COALESCE(locked_until <= now(), true);`
+)
+
+// PostgresAttemptStore shares the sign-in counters between instances.
+type PostgresAttemptStore struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresAttemptStore(db *pgxpool.Pool) *PostgresAttemptStore {
+	return &PostgresAttemptStore{
+		db: db,
+	}
+}
+
+func (ps *PostgresAttemptStore) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	a := &models.LoginAttempts{}
+	if err := ps.db.QueryRow(ctx, getAttempts, key).
+		Scan(&a.Key, &a.Failures, &a.LastFailure, &a.LockedUntil); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.LoginAttempts{Key: key}, nil
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return &models.LoginAttempts{}, err
+	}
+
+	return a, nil
+}
+
+func (ps *PostgresAttemptStore) AddFailure(ctx context.Context, key string, window time.Duration) (*models.LoginAttempts, error) {
+	if _, err := ps.db.Exec(ctx, purgeAttempts, window); err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return &models.LoginAttempts{}, err
+	}
+
+	a := &models.LoginAttempts{}
+	if err := ps.db.QueryRow(ctx, addFailure, key, window).
+		Scan(&a.Key, &a.Failures, &a.LastFailure, &a.LockedUntil); err != nil {
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return &models.LoginAttempts{}, err
+	}
+
+	return a, nil
+}
+
+func (ps *PostgresAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := ps.db.Exec(ctx, lockAttempts, until, key)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ps *PostgresAttemptStore) Reset(ctx context.Context, key string) error {
+	_, err := ps.db.Exec(ctx, resetAttempts, key)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ps *PostgresAttemptStore) ListLocked(ctx context.Context) ([]models.LoginAttempts, error) {
+	rows, err := ps.db.Query(ctx, listLocked)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Query: %w", err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	locked := make([]models.LoginAttempts, 0)
+	for rows.Next() {
+		var a models.LoginAttempts
+		if err = rows.Scan(&a.Key, &a.Failures, &a.LastFailure, &a.LockedUntil); err != nil {
+			err = fmt.Errorf("error happened in rows.Scan: %w", err)
+
+			return nil, err
+		}
+		locked = append(locked, a)
+	}
+
+	return locked, rows.Err()
+}
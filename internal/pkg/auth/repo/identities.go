@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+const (
+	createOIDCState = `INSERT INTO oidc_state (state_hash, nonce, verifier, user_id, expires_at) ` +
+		`VALUES ($1, $2, $3, NULLIF($4, 0), $5);`
+	purgeOIDCStates = `DELETE FROM oidc_state WHERE expires_at <= now();`
+	takeOIDCState   = `DELETE FROM oidc_state WHERE state_hash=$1 AND expires_at > now() ` +
+		`RETURNING nonce, verifier, COALESCE(user_id, 0);`
+	getUserByIdentity = `SELECT u.id, u.login, u.password, u.is_admin, u.disabled FROM "user" u ` +
+		`JOIN user_identity i ON i.user_id = u.id WHERE i.issuer=$1 AND i.subject=$2;`
+	linkIdentity = `INSERT INTO user_identity (user_id, issuer, subject) VALUES ($1, $2, $3);`
+)
+
+func (ar *AuthRepo) CreateOIDCState(ctx context.Context, stateHash string, state *models.OIDCState, expiresAt time.Time) error {
+	if _, err := ar.db.Exec(ctx, purgeOIDCStates); err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	_, err := ar.db.Exec(ctx, createOIDCState, stateHash, state.Nonce, state.Verifier, state.UserId, expiresAt)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// TakeOIDCState returns the pending request and deletes it, so every state is
+// accepted once.
+func (ar *AuthRepo) TakeOIDCState(ctx context.Context, stateHash string) (*models.OIDCState, error) {
+	state := &models.OIDCState{}
+	if err := ar.db.QueryRow(ctx, takeOIDCState, stateHash).
+		Scan(&state.Nonce, &state.Verifier, &state.UserId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.OIDCState{}, auth.ErrInvalidOIDCState
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return &models.OIDCState{}, err
+	}
+
+	return state, nil
+}
+
+func (ar *AuthRepo) GetUserByIdentity(ctx context.Context, issuer string, subject string) (*models.User, error) {
+	u := &models.User{}
+	if err := ar.db.QueryRow(ctx, getUserByIdentity, issuer, subject).
+		Scan(&u.Id, &u.Login, &u.Password, &u.IsAdmin, &u.Disabled); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.User{}, auth.ErrUserNotFound
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return &models.User{}, err
+	}
+
+	return u, nil
+}
+
+func (ar *AuthRepo) LinkIdentity(ctx context.Context, userId int, issuer string, subject string) error {
+	_, err := ar.db.Exec(ctx, linkIdentity, userId, issuer, subject)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return auth.ErrIdentityLinked
+	}
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
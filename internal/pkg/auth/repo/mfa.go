@@ -0,0 +1,200 @@
+package repo
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+const (
+	getTOTP = `SELECT user_id, secret, enabled_at, last_step FROM user_totp WHERE user_id=$1;`
+	setTOTP = `INSERT INTO user_totp (user_id, secret) VALUES ($1, $2) ` +
+		`ON CONFLICT (user_id) DO UPDATE SET secret=$2, enabled_at=NULL, last_step=0 ` +
+		`WHERE user_totp.enabled_at IS NULL;`
+	enableTOTP = `UPDATE user_totp SET enabled_at=now(), last_step=$1 WHERE user_id=$2 AND enabled_at IS NULL;`
+	// the step only moves forward, so a code is accepted once
+	useTOTPStep        = `UPDATE user_totp SET last_step=$1 WHERE user_id=$2 AND last_step < $1;`
+	deleteTOTP         = `DELETE FROM user_totp WHERE user_id=$1;`
+	deleteRecoveryCode = `DELETE FROM recovery_code WHERE user_id=$1;`
+	createRecoveryCode = `INSERT INTO recovery_code (user_id, code_hash) VALUES ($1, $2);`
+	useRecoveryCode    = `UPDATE recovery_code SET used_at=now() WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL;`
+
+	createChallenge = `INSERT INTO mfa_challenge (token_hash, user_id, expires_at) VALUES ($1, $2, $3);`
+	purgeChallenges = `DELETE FROM mfa_challenge WHERE expires_at <= now();`
+	// every attempt is counted before the code is checked
+	attemptChallenge = `UPDATE mfa_challenge SET attempts=attempts+1 ` +
+		`WHERE token_hash=$1 AND expires_at > now() AND attempts < $2 RETURNING user_id;`
+	deleteChallenge = `DELETE FROM mfa_challenge WHERE token_hash=$1;`
+)
+
+func (ar *AuthRepo) GetTOTP(ctx context.Context, userId int) (*models.TOTP, error) {
+	t := &models.TOTP{}
+	if err := ar.db.QueryRow(ctx, getTOTP, userId).
+		Scan(&t.UserId, &t.Secret, &t.EnabledAt, &t.LastStep); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.TOTP{}, auth.ErrTOTPNotEnrolled
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return &models.TOTP{}, err
+	}
+
+	return t, nil
+}
+
+// SetTOTPSecret starts or restarts an enrollment. An enabled authenticator
+// is never replaced.
+func (ar *AuthRepo) SetTOTPSecret(ctx context.Context, userId int, secret string) error {
+	tag, err := ar.db.Exec(ctx, setTOTP, userId, secret)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrTOTPEnabled
+	}
+
+	return nil
+}
+
+// EnableTOTP confirms the enrollment and replaces the recovery codes.
+func (ar *AuthRepo) EnableTOTP(ctx context.Context, userId int, step int64, codeHashes []string) error {
+	err := pgx.BeginFunc(ctx, ar.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, enableTOTP, step, userId)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return auth.ErrTOTPEnabled
+		}
+
+		return replaceRecoveryCodes(ctx, tx, userId, codeHashes)
+	})
+	if errors.Is(err, auth.ErrTOTPEnabled) {
+		return err
+	}
+	if err != nil {
+		err = fmt.Errorf("error happened in tx.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ar *AuthRepo) UseTOTPStep(ctx context.Context, userId int, step int64) (bool, error) {
+	tag, err := ar.db.Exec(ctx, useTOTPStep, step, userId)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (ar *AuthRepo) DeleteTOTP(ctx context.Context, userId int) error {
+	err := pgx.BeginFunc(ctx, ar.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, deleteRecoveryCode, userId); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, deleteTOTP, userId)
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("error happened in tx.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ar *AuthRepo) ReplaceRecoveryCodes(ctx context.Context, userId int, codeHashes []string) error {
+	err := pgx.BeginFunc(ctx, ar.db, func(tx pgx.Tx) error {
+		return replaceRecoveryCodes(ctx, tx, userId, codeHashes)
+	})
+	if err != nil {
+		err = fmt.Errorf("error happened in tx.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userId int, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, deleteRecoveryCode, userId); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(ctx, createRecoveryCode, userId, hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ar *AuthRepo) UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error) {
+	tag, err := ar.db.Exec(ctx, useRecoveryCode, userId, codeHash)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (ar *AuthRepo) CreateMFAChallenge(ctx context.Context, tokenHash string, userId int, expiresAt time.Time) error {
+	if _, err := ar.db.Exec(ctx, purgeChallenges); err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	_, err := ar.db.Exec(ctx, createChallenge, tokenHash, userId, expiresAt)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// AttemptMFAChallenge counts an attempt and returns the user of the
+// challenge, or ErrInvalidChallenge once it is expired or out of attempts.
+func (ar *AuthRepo) AttemptMFAChallenge(ctx context.Context, tokenHash string, maxAttempts int) (int, error) {
+	var userId int
+	if err := ar.db.QueryRow(ctx, attemptChallenge, tokenHash, maxAttempts).Scan(&userId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, auth.ErrInvalidChallenge
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return 0, err
+	}
+
+	return userId, nil
+}
+
+func (ar *AuthRepo) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	_, err := ar.db.Exec(ctx, deleteChallenge, tokenHash)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
@@ -2,13 +2,56 @@ package repo
 
 import (
 	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
 )
 
+// uniqueViolation is the SQLSTATE of a unique constraint violation
+const uniqueViolation = "23505"
+
 const (
-	createUser = `INSERT INTO "user" (login, password, is_admin) VALUES ($1, $2, $3) RETURNING id;`
+	createUser     = `INSERT INTO "user" (login, password, is_admin) VALUES ($1, $2, $3) RETURNING id;`
+	getUserByLogin = `SELECT id, login, password, is_admin, disabled FROM "user" WHERE login=$1;`
+	getUserById    = `SELECT id, login, password, is_admin, disabled FROM "user" WHERE id=$1;`
+	updatePassword = `UPDATE "user" SET password=$1 WHERE id=$2;`
+	// plaintext passwords of the accounts created before passwords were hashed,
+	// neither hashes nor unusable values, which start with !
+	getLegacyPasswords = `SELECT id, password FROM "user" ` +
+		`WHERE password <> '' AND password NOT LIKE '$argon2id$%' AND password NOT LIKE '!%';`
+	migratePassword = `UPDATE "user" SET password=$1 WHERE id=$2 AND password=$3;`
+
+	createSession      = `INSERT INTO session (user_id, device, mfa, auth_time) VALUES ($1, $2, $3, $4) RETURNING id;`
+	getSession         = `SELECT id, user_id, device, created_at, revoked_at, mfa, auth_time FROM session WHERE id=$1;`
+	revokeSession      = `UPDATE session SET revoked_at=now() WHERE id=$1 AND revoked_at IS NULL;`
+	revokeUserSessions = `UPDATE session SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL;`
+	revokeOtherSession = `UPDATE session SET revoked_at=now() WHERE user_id=$1 AND id<>$2 AND revoked_at IS NULL;`
+	setSessionAccess   = `UPDATE session SET access_jti=$1, access_expires_at=$2 WHERE id=$3;`
+	createRefreshToken = `INSERT INTO refresh_token (session_id, token_hash, expires_at) VALUES ($1, $2, $3);`
+	getRefreshToken    = `SELECT id, session_id, token_hash, expires_at, used_at FROM refresh_token WHERE token_hash=$1;`
+	useRefreshToken    = `UPDATE refresh_token SET used_at=now() WHERE id=$1 AND used_at IS NULL;`
+
+	revokeToken        = `INSERT INTO revoked_token (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING;`
+	purgeRevokedTokens = `DELETE FROM revoked_token WHERE expires_at <= now();`
+	// a token whose session has been revoked or is gone counts as revoked,
+	// whichever access token of the session it is
+	getTokenStatus = `SELECT EXISTS (SELECT 1 FROM revoked_token WHERE jti=$1) OR ($3 <> 0 AND ` +
+		`COALESCE((SELECT revoked_at IS NOT NULL FROM session WHERE id=$3 AND user_id=$2), true)), ` +
+		`COALESCE((SELECT disabled FROM "user" WHERE id=$2), true);`
+	revokeSessionToken = `INSERT INTO revoked_token (jti, expires_at) ` +
+		`SELECT access_jti, access_expires_at FROM session ` +
+		`WHERE id=$1 AND revoked_at IS NULL AND access_expires_at > now() ON CONFLICT (jti) DO NOTHING;`
+	revokeUserTokens = `INSERT INTO revoked_token (jti, expires_at) ` +
+		`SELECT access_jti, access_expires_at FROM session ` +
+		`WHERE user_id=$1 AND revoked_at IS NULL AND access_expires_at > now() ON CONFLICT (jti) DO NOTHING;`
+	revokeOtherTokens = `INSERT INTO revoked_token (jti, expires_at) ` +
+		`SELECT access_jti, access_expires_at FROM session ` +
+		`WHERE user_id=$1 AND id<>$2 AND revoked_at IS NULL AND access_expires_at > now() ON CONFLICT (jti) DO NOTHING;`
 )
 
 type AuthRepo struct {
@@ -26,6 +69,10 @@ func (ar *AuthRepo) CreateUser(ctx context.Context, user *models.User) (int, err
 	err := ar.db.QueryRow(ctx, createUser,
 		user.Login, user.Password, user.IsAdmin).Scan(&id)
 
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return 0, auth.ErrLoginTaken
+	}
 	if err != nil {
 		err = fmt.Errorf("error happened in scan.Scan: %w", err)
 
@@ -35,6 +82,257 @@ func (ar *AuthRepo) CreateUser(ctx context.Context, user *models.User) (int, err
 	return id, nil
 }
 
-func (ar *AuthRepo) GetUserByLogin(context.Context, string) (*models.User, error) {
-	return &models.User{}, nil
+func (ar *AuthRepo) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	u := &models.User{}
+	if err := ar.db.QueryRow(ctx, getUserByLogin, login).
+		Scan(&u.Id, &u.Login, &u.Password, &u.IsAdmin, &u.Disabled); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.User{}, auth.ErrUserNotFound
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return &models.User{}, err
+	}
+
+	return u, nil
+}
+
+func (ar *AuthRepo) GetUserById(ctx context.Context, id int) (*models.User, error) {
+	u := &models.User{}
+	if err := ar.db.QueryRow(ctx, getUserById, id).
+		Scan(&u.Id, &u.Login, &u.Password, &u.IsAdmin, &u.Disabled); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.User{}, auth.ErrUserNotFound
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return &models.User{}, err
+	}
+
+	return u, nil
+}
+
+func (ar *AuthRepo) UpdatePassword(ctx context.Context, id int, password string) error {
+	_, err := ar.db.Exec(ctx, updatePassword, password, id)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ar *AuthRepo) GetLegacyPasswords(ctx context.Context) (map[int]string, error) {
+	rows, err := ar.db.Query(ctx, getLegacyPasswords)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Query: %w", err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	passwords := make(map[int]string)
+	var id int
+	var password string
+	for rows.Next() {
+		if err = rows.Scan(&id, &password); err != nil {
+			err = fmt.Errorf("error happened in rows.Scan: %w", err)
+
+			return nil, err
+		}
+
+		passwords[id] = password
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error happened in rows.Next: %w", err)
+
+		return nil, err
+	}
+
+	return passwords, nil
+}
+
+// MigratePassword replaces the legacy password of the user with its hash,
+// unless the password has been changed meanwhile.
+func (ar *AuthRepo) MigratePassword(ctx context.Context, id int, legacy string, hash string) error {
+	_, err := ar.db.Exec(ctx, migratePassword, hash, id, legacy)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ar *AuthRepo) CreateSession(ctx context.Context, session *models.Session) (int, error) {
+	var id int
+	err := ar.db.QueryRow(ctx, createSession, session.UserId, session.Device, session.Mfa, session.AuthTime).Scan(&id)
+	if err != nil {
+		err = fmt.Errorf("error happened in scan.Scan: %w", err)
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (ar *AuthRepo) GetSession(ctx context.Context, id int) (*models.Session, error) {
+	s := &models.Session{}
+	if err := ar.db.QueryRow(ctx, getSession, id).
+		Scan(&s.Id, &s.UserId, &s.Device, &s.CreatedAt, &s.RevokedAt, &s.Mfa, &s.AuthTime); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.Session{}, auth.ErrInvalidRefreshToken
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return &models.Session{}, err
+	}
+
+	return s, nil
+}
+
+// RevokeSession revokes the session together with the last access token
+// issued in it.
+func (ar *AuthRepo) RevokeSession(ctx context.Context, id int) error {
+	err := pgx.BeginFunc(ctx, ar.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, revokeSessionToken, id); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, revokeSession, id)
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("error happened in tx.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ar *AuthRepo) RevokeUserSessions(ctx context.Context, userId int) error {
+	err := pgx.BeginFunc(ctx, ar.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, revokeUserTokens, userId); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, revokeUserSessions, userId)
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("error happened in tx.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions revokes every session of the user except the given one.
+func (ar *AuthRepo) RevokeOtherSessions(ctx context.Context, userId int, sessionId int) error {
+	err := pgx.BeginFunc(ctx, ar.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, revokeOtherTokens, userId, sessionId); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, revokeOtherSession, userId, sessionId)
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("error happened in tx.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ar *AuthRepo) SetSessionAccessToken(ctx context.Context, sessionId int, jti string, expiresAt time.Time) error {
+	_, err := ar.db.Exec(ctx, setSessionAccess, jti, expiresAt, sessionId)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// RevokeToken puts the access token on the denylist until it expires.
+// Entries that have outlived their token are dropped on the way.
+func (ar *AuthRepo) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := ar.db.Exec(ctx, revokeToken, jti, expiresAt)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	_, err = ar.db.Exec(ctx, purgeRevokedTokens)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// GetTokenStatus reports whether the access token is on the denylist or its
+// session is revoked, and whether its user is disabled. A deleted user counts
+// as disabled. Tokens without a session, sessionId 0, are only looked up in
+// the denylist.
+func (ar *AuthRepo) GetTokenStatus(ctx context.Context, jti string, userId int, sessionId int) (bool, bool, error) {
+	var revoked, disabled bool
+	err := ar.db.QueryRow(ctx, getTokenStatus, jti, userId, sessionId).Scan(&revoked, &disabled)
+	if err != nil {
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return false, false, err
+	}
+
+	return revoked, disabled, nil
+}
+
+func (ar *AuthRepo) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	_, err := ar.db.Exec(ctx, createRefreshToken, token.SessionId, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (ar *AuthRepo) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	t := &models.RefreshToken{}
+	if err := ar.db.QueryRow(ctx, getRefreshToken, tokenHash).
+		Scan(&t.Id, &t.SessionId, &t.TokenHash, &t.ExpiresAt, &t.UsedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.RefreshToken{}, auth.ErrInvalidRefreshToken
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return &models.RefreshToken{}, err
+	}
+
+	return t, nil
+}
+
+// UseRefreshToken marks the token as used. It reports false when the token
+// had already been used, so concurrent refreshes cannot both succeed.
+func (ar *AuthRepo) UseRefreshToken(ctx context.Context, id int) (bool, error) {
+	tag, err := ar.db.Exec(ctx, useRefreshToken, id)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
 }
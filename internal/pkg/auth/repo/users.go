@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+const (
+	listUsers        = `SELECT id, login, is_admin, disabled, count(*) OVER() FROM "user" ORDER BY id LIMIT $1 OFFSET $2;`
+	setAdmin         = `UPDATE "user" SET is_admin=$1 WHERE id=$2;`
+	setDisabled      = `UPDATE "user" SET disabled=$1 WHERE id=$2;`
+	deleteUser       = `DELETE FROM "user" WHERE id=$1;`
+	setPasswordReset = `UPDATE "user" SET password=$1, reset_token_hash=$2, reset_expires_at=$3 WHERE id=$4;`
+	resetPassword    = `UPDATE "user" SET password=$1, reset_token_hash=NULL, reset_expires_at=NULL ` +
+		`WHERE reset_token_hash=$2 AND reset_expires_at > now() RETURNING id;`
+)
+
+func (ar *AuthRepo) ListUsers(ctx context.Context, limit int, offset int) ([]models.User, int, error) {
+	rows, err := ar.db.Query(ctx, listUsers, limit, offset)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Query: %w", err)
+
+		return []models.User{}, 0, err
+	}
+	defer rows.Close()
+
+	userSlice := make([]models.User, 0)
+	total := 0
+	user := models.User{}
+	for rows.Next() {
+		err = rows.Scan(&user.Id, &user.Login, &user.IsAdmin, &user.Disabled, &total)
+		if err != nil {
+			err = fmt.Errorf("error happened in rows.Scan: %w", err)
+
+			return []models.User{}, 0, err
+		}
+
+		userSlice = append(userSlice, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error happened in rows.Next: %w", err)
+
+		return []models.User{}, 0, err
+	}
+
+	return userSlice, total, nil
+}
+
+func (ar *AuthRepo) SetAdmin(ctx context.Context, id int, isAdmin bool) error {
+	return ar.execForUser(ctx, setAdmin, isAdmin, id)
+}
+
+func (ar *AuthRepo) SetDisabled(ctx context.Context, id int, disabled bool) error {
+	return ar.execForUser(ctx, setDisabled, disabled, id)
+}
+
+func (ar *AuthRepo) DeleteUser(ctx context.Context, id int) error {
+	return ar.execForUser(ctx, deleteUser, id)
+}
+
+// SetPasswordReset replaces the password of the user with an unusable one,
+// which no password matches, and stores the hash of a one-time reset token.
+func (ar *AuthRepo) SetPasswordReset(ctx context.Context, id int, unusable string, tokenHash string, expiresAt time.Time) error {
+	return ar.execForUser(ctx, setPasswordReset, unusable, tokenHash, expiresAt, id)
+}
+
+func (ar *AuthRepo) ResetPassword(ctx context.Context, tokenHash string, password string) (int, error) {
+	var id int
+	err := ar.db.QueryRow(ctx, resetPassword, password, tokenHash).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, auth.ErrInvalidResetToken
+		}
+		err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (ar *AuthRepo) execForUser(ctx context.Context, query string, args ...any) error {
+	tag, err := ar.db.Exec(ctx, query, args...)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
+
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/audit"
+	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/utils/hasher"
+	"context"
+	"time"
+)
+
+// ChangePassword sets a new password after checking the old one, or sets the
+// first password of an SSO account signed in recently. All other sessions of
+// the user are ended, the current one stays.
+func (au *AuthUsecase) ChangePassword(ctx context.Context, claims *models.JwtClaims, oldPassword string, newPassword string) error {
+	if err := au.confirmIdentity(ctx, claims, oldPassword); err != nil {
+		return err
+	}
+
+	if err := au.checkPasswordPolicy("newPassword", newPassword); err != nil {
+		return err
+	}
+
+	hash, err := au.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err = au.repo.UpdatePassword(ctx, claims.UserId, hash); err != nil {
+		return err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "password_change", Entity: audit.EntityUser, EntityId: claims.UserId})
+	return au.repo.RevokeOtherSessions(ctx, claims.UserId, claims.SessionId)
+}
+
+// DeleteAccount deletes the account of the current user once the password is
+// confirmed, or for an SSO account when it signed in recently.
+func (au *AuthUsecase) DeleteAccount(ctx context.Context, claims *models.JwtClaims, password string) error {
+	if err := au.confirmIdentity(ctx, claims, password); err != nil {
+		return err
+	}
+
+	if err := au.repo.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return err
+	}
+
+	return au.DeleteUser(ctx, claims.UserId)
+}
+
+// confirmIdentity asks a signed-in user to prove again who they are before a
+// sensitive change: with the password, or for accounts without one, which
+// sign in through SSO only, by a sign-in within the ReauthWindow. Wrong
+// passwords lock the login out like failed sign-ins.
+func (au *AuthUsecase) confirmIdentity(ctx context.Context, claims *models.JwtClaims, password string) error {
+	u, err := au.repo.GetUserById(ctx, claims.UserId)
+	if err != nil {
+		return err
+	}
+
+	if !hasher.Usable(u.Password) {
+		authTime := time.Unix(claims.AuthTime, 0)
+		if claims.AuthTime == 0 || time.Since(authTime) > au.cfg.ReauthWindow {
+			return auth.ErrReauthRequired
+		}
+
+		return nil
+	}
+
+	return au.throttle(ctx, u.Login, func() error {
+		match, needsRehash, err := au.hasher.Verify(password, u.Password)
+		if err != nil {
+			return err
+		}
+		if !match {
+			return auth.ErrInvalidCredentials
+		}
+
+		if needsRehash {
+			au.rehash(ctx, u.Id, password)
+		}
+
+		return nil
+	})
+}
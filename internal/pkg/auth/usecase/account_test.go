@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/utils/hasher"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// accountRepo has one user, alice, whose password is "old password".
+type accountRepo struct {
+	*oidcRepo
+	updates int
+}
+
+func newAccountRepo(t *testing.T, h hasher.PasswordHasher) *accountRepo {
+	t.Helper()
+
+	hash, err := h.Hash("old password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	repo := &accountRepo{oidcRepo: newOIDCRepo()}
+	repo.users["alice"] = &models.User{Id: 1, Login: "alice", Password: hash}
+	return repo
+}
+
+func (r *accountRepo) UpdatePassword(_ context.Context, id int, hash string) error {
+	r.updates++
+	r.users["alice"].Password = hash
+	return nil
+}
+
+func (r *accountRepo) RevokeOtherSessions(context.Context, int, int) error {
+	return nil
+}
+
+func (r *accountRepo) DeleteUser(_ context.Context, id int) error {
+	delete(r.users, "alice")
+	return nil
+}
+
+func TestPasswordConfirmationIsLockedOut(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		check func(au *AuthUsecase, password string) error
+	}{
+		{"change password", func(au *AuthUsecase, password string) error {
+			return au.ChangePassword(ctx, claims("jti", 1, 1), password, "a new password 42")
+		}},
+		{"delete account", func(au *AuthUsecase, password string) error {
+			return au.DeleteAccount(ctx, claims("jti", 1, 1), password)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			au := newTestAuthUsecase(t, nil)
+			au.repo = newAccountRepo(t, au.hasher)
+
+			if err := tt.check(au, "wrong password"); !errors.Is(err, auth.ErrInvalidCredentials) {
+				t.Fatalf("first wrong password: %v, want ErrInvalidCredentials", err)
+			}
+
+			for i := 0; i < 3; i++ {
+				var lockedErr *auth.LockedError
+				if err := tt.check(au, "wrong password"); !errors.As(err, &lockedErr) || lockedErr.RetryAfter <= 0 {
+					t.Fatalf("wrong password %d: %v, want a lockout", i+2, err)
+				}
+			}
+
+			if err := tt.check(au, "old password"); !errors.Is(err, auth.ErrTooManyAttempts) {
+				t.Errorf("right password while locked out: %v, want ErrTooManyAttempts", err)
+			}
+		})
+	}
+}
+
+func TestPasswordConfirmationRehashes(t *testing.T) {
+	old, err := hasher.NewArgon2Hasher(hasher.Params{Memory: 32, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32})
+	if err != nil {
+		t.Fatalf("NewArgon2Hasher: %v", err)
+	}
+
+	au := newTestAuthUsecase(t, nil)
+	repo := newAccountRepo(t, old)
+	au.repo = repo
+
+	if err = au.confirmIdentity(context.Background(), claims("jti", 1, 1), "old password"); err != nil {
+		t.Fatalf("confirmIdentity: %v", err)
+	}
+	if repo.updates != 1 {
+		t.Fatalf("password updated %d times, want once", repo.updates)
+	}
+
+	match, needsRehash, err := au.hasher.Verify("old password", repo.users["alice"].Password)
+	if err != nil || !match || needsRehash {
+		t.Errorf("Verify of the new hash = %v, %v, %v; want a match with current parameters", match, needsRehash, err)
+	}
+}
+
+func TestSSOAccountConfirmsWithARecentSignIn(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		authTime time.Time
+		wantErr  error
+	}{
+		{"recent SSO sign-in", time.Now().Add(-time.Minute), nil},
+		{"old SSO sign-in", time.Now().Add(-DefaultReauthWindow - time.Minute), auth.ErrReauthRequired},
+		{"unknown sign-in time", time.Time{}, auth.ErrReauthRequired},
+	}
+
+	actions := []struct {
+		name string
+		do   func(au *AuthUsecase, c *models.JwtClaims) error
+	}{
+		{"set a first password", func(au *AuthUsecase, c *models.JwtClaims) error {
+			return au.ChangePassword(ctx, c, "", "A new password 42")
+		}},
+		{"delete account", func(au *AuthUsecase, c *models.JwtClaims) error {
+			return au.DeleteAccount(ctx, c, "")
+		}},
+	}
+
+	for _, tt := range tests {
+		for _, action := range actions {
+			t.Run(tt.name+"/"+action.name, func(t *testing.T) {
+				au := newTestAuthUsecase(t, nil)
+				repo := newAccountRepo(t, au.hasher)
+				repo.users["alice"].Password = hasher.Unusable("sso")
+				au.repo = repo
+
+				c := claims("jti", 1, 1)
+				if !tt.authTime.IsZero() {
+					c.AuthTime = tt.authTime.Unix()
+				}
+
+				if err := action.do(au, c); !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/apperr"
+	"MovieService/internal/pkg/audit"
+	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/policy"
+	"MovieService/internal/pkg/utils/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// API keys look like "msk_<prefix>_<secret>"; "msk_<prefix>" is shown in
+	// listings and logs.
+	apiKeyScheme    = "msk_"
+	apiKeyPrefixLen = 4
+	apiKeyNameMax   = 64
+)
+
+func (au *AuthUsecase) CreateApiKey(ctx context.Context, claims *models.JwtClaims, key *models.ApiKey) (*models.NewApiKey, error) {
+	fields := make(map[string]string)
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" || utf8.RuneCountInString(key.Name) > apiKeyNameMax {
+		fields["name"] = "must be 1 to 64 characters long"
+	}
+
+	if len(key.Permissions) == 0 {
+		fields["permissions"] = "must not be empty"
+	}
+	for _, perm := range key.Permissions {
+		if !policy.Valid(perm) {
+			fields["permissions"] = "unknown permission " + perm
+			break
+		}
+	}
+
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		fields["expiresAt"] = "must be in the future"
+	}
+
+	if len(fields) > 0 {
+		return nil, apperr.Validation(fields)
+	}
+
+	b := make([]byte, apiKeyPrefixLen)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	key.UserId = claims.UserId
+	key.Prefix = apiKeyScheme + hex.EncodeToString(b)
+	plain := key.Prefix + "_" + secret
+
+	if _, err = au.repo.CreateApiKey(ctx, key, hashToken(plain)); err != nil {
+		return nil, err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "create", Entity: audit.EntityApiKey, EntityId: key.Id, After: key})
+
+	return &models.NewApiKey{ApiKey: *key, Key: plain}, nil
+}
+
+func (au *AuthUsecase) ListApiKeys(ctx context.Context, claims *models.JwtClaims) ([]models.ApiKey, error) {
+	return au.repo.ListApiKeys(ctx, claims.UserId)
+}
+
+func (au *AuthUsecase) RevokeApiKey(ctx context.Context, claims *models.JwtClaims, id int) error {
+	if err := au.repo.RevokeApiKey(ctx, claims.UserId, id); err != nil {
+		return err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "revoke", Entity: audit.EntityApiKey, EntityId: id})
+	return nil
+}
+
+// CheckApiKey resolves the key into claims of its owner. The permissions of
+// the key end up in Scopes and narrow down what the role of the owner allows.
+func (au *AuthUsecase) CheckApiKey(ctx context.Context, plain string) (*models.JwtClaims, error) {
+	if !strings.HasPrefix(plain, apiKeyScheme) {
+		return nil, auth.ErrInvalidApiKey
+	}
+
+	key, err := au.repo.GetApiKeyByHash(ctx, hashToken(plain))
+	if err != nil {
+		return nil, err
+	}
+
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now())) {
+		return nil, auth.ErrInvalidApiKey
+	}
+
+	user, err := au.repo.GetUserById(ctx, key.UserId)
+	if errors.Is(err, auth.ErrUserNotFound) {
+		return nil, auth.ErrInvalidApiKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Disabled {
+		return nil, auth.ErrUserDisabled
+	}
+
+	if err = au.repo.TouchApiKey(ctx, key.Id); err != nil {
+		logger.FromContext(ctx).Error("failed to record use of API key", "prefix", key.Prefix, "error", err)
+	}
+
+	return &models.JwtClaims{
+		UserId:   user.Id,
+		IsAdmin:  user.IsAdmin,
+		ApiKeyId: key.Id,
+		Scopes:   key.Permissions,
+	}, nil
+}
+
+// PruneApiKeys deletes the revoked and expired keys and those not used for
+// longer than unusedFor.
+func (au *AuthUsecase) PruneApiKeys(ctx context.Context, unusedFor time.Duration) (int64, error) {
+	deleted, err := au.repo.PruneApiKeys(ctx, time.Now().Add(-unusedFor))
+	if err != nil {
+		return 0, err
+	}
+
+	au.audit.Record(ctx, audit.Entry{
+		Action: "prune",
+		Entity: audit.EntityApiKey,
+		After:  map[string]interface{}{"unusedFor": unusedFor.String(), "deleted": deleted},
+	})
+	return deleted, nil
+}
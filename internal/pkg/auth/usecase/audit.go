@@ -0,0 +1,17 @@
+package usecase
+
+import (
+	"MovieService/internal/pkg/audit"
+	"context"
+)
+
+// recordSignInFailure records a rejected sign-in under the login that was
+// tried, since there is no authenticated user yet.
+func (au *AuthUsecase) recordSignInFailure(ctx context.Context, action string, login string, reason error) {
+	au.audit.Record(ctx, audit.Entry{
+		Action:   action,
+		Entity:   audit.EntityLogin,
+		EntityId: login,
+		After:    map[string]string{"reason": reason.Error()},
+	})
+}
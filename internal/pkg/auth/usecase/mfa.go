@@ -0,0 +1,303 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/audit"
+	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/utils/totp"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+)
+
+type MFAConfig struct {
+	// Issuer is the account name shown in authenticator apps.
+	Issuer string
+	// ChallengeTTL and ChallengeAttempts bound the second step of sign-in;
+	// failed codes also count towards the sign-in lockout.
+	ChallengeTTL      time.Duration
+	ChallengeAttempts int
+	RecoveryCodes     int
+}
+
+const recoveryCodeSize = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var DefaultMFAConfig = MFAConfig{
+	Issuer:            "MovieService",
+	ChallengeTTL:      5 * time.Minute,
+	ChallengeAttempts: 5,
+	RecoveryCodes:     10,
+}
+
+// ChallengeMFA returns a challenge when the user has an authenticator
+// enabled and nil otherwise. Every way of signing in goes through it before a
+// session is started.
+func (au *AuthUsecase) ChallengeMFA(ctx context.Context, user *models.User) (*models.MFAChallenge, error) {
+	t, err := au.repo.GetTOTP(ctx, user.Id)
+	if errors.Is(err, auth.ErrTOTPNotEnrolled) || (err == nil && t.EnabledAt == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(au.cfg.MFA.ChallengeTTL)
+	if err = au.repo.CreateMFAChallenge(ctx, hashToken(token), user.Id, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &models.MFAChallenge{MfaRequired: true, ChallengeToken: token, ExpiresAt: expiresAt}, nil
+}
+
+// CompleteSignIn checks the second factor of a sign-in challenge and starts
+// a session that counts as multi-factor.
+func (au *AuthUsecase) CompleteSignIn(ctx context.Context, v *models.MFAVerification, device string, ip string) (*models.TokenPair, error) {
+	tokenHash := hashToken(v.ChallengeToken)
+	userId, err := au.repo.AttemptMFAChallenge(ctx, tokenHash, au.cfg.MFA.ChallengeAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := au.repo.GetUserById(ctx, userId)
+	if errors.Is(err, auth.ErrUserNotFound) {
+		return nil, auth.ErrInvalidChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{loginKey(user.Login), ipKey(ip)}
+	if err = au.checkLockout(ctx, keys); err != nil {
+		au.recordSignInFailure(ctx, "sign_in_locked", user.Login, err)
+		return nil, err
+	}
+
+	if user.Disabled {
+		return nil, auth.ErrUserDisabled
+	}
+
+	err = au.verifySecondFactor(ctx, user.Id, v.Code, v.RecoveryCode)
+	if errors.Is(err, auth.ErrInvalidOTP) {
+		au.recordSignInFailure(ctx, "mfa_failed", user.Login, err)
+		if err = au.registerFailure(ctx, keys); !errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, err
+		}
+		return nil, auth.ErrInvalidOTP
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = au.repo.DeleteMFAChallenge(ctx, tokenHash); err != nil {
+		return nil, err
+	}
+
+	if err = au.attempts.Reset(ctx, loginKey(user.Login)); err != nil {
+		return nil, err
+	}
+
+	return au.startSession(ctx, user, device, true, time.Now())
+}
+
+// EnrollTOTP generates a new secret. It is not used for sign-in until a code
+// is confirmed with ConfirmTOTP.
+func (au *AuthUsecase) EnrollTOTP(ctx context.Context, claims *models.JwtClaims) (*models.TOTPEnrollment, error) {
+	user, err := au.repo.GetUserById(ctx, claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = au.repo.SetTOTPSecret(ctx, user.Id, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(au.cfg.MFA.Issuer, user.Login, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables the enrolled authenticator once it produced a valid
+// code and returns the recovery codes, which are shown only this once.
+func (au *AuthUsecase) ConfirmTOTP(ctx context.Context, claims *models.JwtClaims, code string) (*models.RecoveryCodes, error) {
+	user, err := au.repo.GetUserById(ctx, claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := au.repo.GetTOTP(ctx, claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.EnabledAt != nil {
+		return nil, auth.ErrTOTPEnabled
+	}
+
+	var step int64
+	err = au.throttle(ctx, user.Login, func() error {
+		var ok bool
+		if step, ok = totp.Validate(t.Secret, code, time.Now()); !ok {
+			return auth.ErrInvalidOTP
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := au.recoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = au.repo.EnableTOTP(ctx, claims.UserId, step, hashes); err != nil {
+		return nil, err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "enable", Entity: audit.EntityTOTP, EntityId: claims.UserId})
+
+	return codes, nil
+}
+
+// DisableTOTP removes the authenticator and the recovery codes. A current
+// code is asked and wrong codes lock the login out like failed sign-ins, so a
+// stolen access token alone cannot turn 2FA off.
+func (au *AuthUsecase) DisableTOTP(ctx context.Context, claims *models.JwtClaims, code string) error {
+	if err := au.checkSecondFactor(ctx, claims.UserId, code); err != nil {
+		return err
+	}
+
+	if err := au.repo.DeleteTOTP(ctx, claims.UserId); err != nil {
+		return err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "disable", Entity: audit.EntityTOTP, EntityId: claims.UserId})
+	return nil
+}
+
+func (au *AuthUsecase) RegenerateRecoveryCodes(ctx context.Context, claims *models.JwtClaims, code string) (*models.RecoveryCodes, error) {
+	if err := au.checkSecondFactor(ctx, claims.UserId, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := au.recoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = au.repo.ReplaceRecoveryCodes(ctx, claims.UserId, hashes); err != nil {
+		return nil, err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "regenerate_recovery_codes", Entity: audit.EntityTOTP, EntityId: claims.UserId})
+
+	return codes, nil
+}
+
+// ResetTOTP is the admin way out for a user who lost both the authenticator
+// and the recovery codes.
+func (au *AuthUsecase) ResetTOTP(ctx context.Context, userId int) error {
+	if _, err := au.repo.GetUserById(ctx, userId); err != nil {
+		return err
+	}
+
+	if err := au.repo.DeleteTOTP(ctx, userId); err != nil {
+		return err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "reset", Entity: audit.EntityTOTP, EntityId: userId})
+	return nil
+}
+
+// checkSecondFactor verifies a code of the authenticator of a signed-in user
+// under the lockout of their login.
+func (au *AuthUsecase) checkSecondFactor(ctx context.Context, userId int, code string) error {
+	user, err := au.repo.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	return au.throttle(ctx, user.Login, func() error {
+		return au.verifySecondFactor(ctx, userId, code, "")
+	})
+}
+
+func (au *AuthUsecase) verifySecondFactor(ctx context.Context, userId int, code string, recoveryCode string) error {
+	t, err := au.repo.GetTOTP(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if t.EnabledAt == nil {
+		return auth.ErrTOTPNotEnrolled
+	}
+
+	if recoveryCode != "" {
+		ok, err := au.repo.UseRecoveryCode(ctx, userId, hashToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return auth.ErrInvalidOTP
+		}
+
+		return nil
+	}
+
+	step, ok := totp.Validate(t.Secret, code, time.Now())
+	if !ok {
+		return auth.ErrInvalidOTP
+	}
+
+	// a code seen once is rejected even within its time window
+	ok, err = au.repo.UseTOTPStep(ctx, userId, step)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return auth.ErrInvalidOTP
+	}
+
+	return nil
+}
+
+// recoveryCodes generates codes like "k3vq-7zpa-m2xd-4hcf" and their hashes.
+// A code holds 80 random bits, too many to be found from its hash even though
+// the hash is fast and unsalted.
+func (au *AuthUsecase) recoveryCodes() (*models.RecoveryCodes, []string, error) {
+	codes := make([]string, 0, au.cfg.MFA.RecoveryCodes)
+	hashes := make([]string, 0, au.cfg.MFA.RecoveryCodes)
+	for i := 0; i < au.cfg.MFA.RecoveryCodes; i++ {
+		b := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		codes = append(codes, code[:4]+"-"+code[4:8]+"-"+code[8:12]+"-"+code[12:])
+		hashes = append(hashes, hashToken(code))
+	}
+
+	return &models.RecoveryCodes{Codes: codes}, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/utils/totp"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mfaRepo has one user, alice, with an authenticator that is enabled or only
+// enrolled.
+type mfaRepo struct {
+	*oidcRepo
+	totp *models.TOTP
+}
+
+func newMFARepo(t *testing.T, enabled bool) *mfaRepo {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+
+	repo := &mfaRepo{oidcRepo: newOIDCRepo(), totp: &models.TOTP{UserId: 1, Secret: secret}}
+	repo.users["alice"] = &models.User{Id: 1, Login: "alice"}
+	if enabled {
+		enabledAt := time.Now()
+		repo.totp.EnabledAt = &enabledAt
+	}
+
+	return repo
+}
+
+func (r *mfaRepo) GetTOTP(context.Context, int) (*models.TOTP, error) {
+	t := *r.totp
+	return &t, nil
+}
+
+func (r *mfaRepo) UseTOTPStep(context.Context, int, int64) (bool, error) {
+	return true, nil
+}
+
+func (r *mfaRepo) EnableTOTP(context.Context, int, int64, []string) error {
+	return nil
+}
+
+func (r *mfaRepo) DeleteTOTP(context.Context, int) error {
+	return nil
+}
+
+func (r *mfaRepo) ReplaceRecoveryCodes(context.Context, int, []string) error {
+	return nil
+}
+
+func TestSecondFactorChecksAreLockedOut(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		enabled bool
+		check   func(au *AuthUsecase, c *models.JwtClaims, code string) error
+	}{
+		{"confirm", false, func(au *AuthUsecase, c *models.JwtClaims, code string) error {
+			_, err := au.ConfirmTOTP(ctx, c, code)
+			return err
+		}},
+		{"disable", true, func(au *AuthUsecase, c *models.JwtClaims, code string) error {
+			return au.DisableTOTP(ctx, c, code)
+		}},
+		{"regenerate recovery codes", true, func(au *AuthUsecase, c *models.JwtClaims, code string) error {
+			_, err := au.RegenerateRecoveryCodes(ctx, c, code)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMFARepo(t, tt.enabled)
+			au := newTestAuthUsecase(t, repo)
+			c := claims("jti", 1, 1)
+
+			code, err := totp.Generate(repo.totp.Secret, time.Now())
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			wrong := "000000"
+			if code == wrong {
+				wrong = "111111"
+			}
+
+			if err = tt.check(au, c, wrong); !errors.Is(err, auth.ErrInvalidOTP) {
+				t.Fatalf("first wrong code: %v, want ErrInvalidOTP", err)
+			}
+
+			for i := 0; i < 3; i++ {
+				var lockedErr *auth.LockedError
+				if err = tt.check(au, c, wrong); !errors.As(err, &lockedErr) || lockedErr.RetryAfter <= 0 {
+					t.Fatalf("wrong code %d: %v, want a lockout", i+2, err)
+				}
+			}
+
+			// while locked out not even the right code is checked
+			if err = tt.check(au, c, code); !errors.Is(err, auth.ErrTooManyAttempts) {
+				t.Errorf("right code while locked out: %v, want ErrTooManyAttempts", err)
+			}
+		})
+	}
+}
+
+func TestSecondFactorCheckResetsTheLockout(t *testing.T) {
+	repo := newMFARepo(t, true)
+	au := newTestAuthUsecase(t, repo)
+	au.cfg.Throttle.BaseDelay = 0
+
+	code, err := totp.Generate(repo.totp.Secret, time.Now())
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	for i := 0; i < au.cfg.Throttle.LoginThreshold-1; i++ {
+		if err = au.DisableTOTP(context.Background(), claims("jti", 1, 1), "abcdef"); !errors.Is(err, auth.ErrInvalidOTP) {
+			t.Fatalf("wrong code %d: %v, want ErrInvalidOTP", i+1, err)
+		}
+	}
+
+	if err = au.DisableTOTP(context.Background(), claims("jti", 1, 1), code); err != nil {
+		t.Fatalf("right code: %v", err)
+	}
+
+	attempts, err := au.attempts.Get(context.Background(), loginKey("alice"))
+	if err != nil || attempts.Failures != 0 {
+		t.Errorf("failures after the right code = %v, %v; want 0", attempts, err)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	au := newTestAuthUsecase(t, newMFARepo(t, true))
+
+	codes, hashes, err := au.recoveryCodes()
+	if err != nil {
+		t.Fatalf("recoveryCodes: %v", err)
+	}
+	if len(codes.Codes) != DefaultMFAConfig.RecoveryCodes || len(hashes) != len(codes.Codes) {
+		t.Fatalf("got %d codes and %d hashes, want %d", len(codes.Codes), len(hashes), DefaultMFAConfig.RecoveryCodes)
+	}
+
+	seen := make(map[string]bool)
+	for i, code := range codes.Codes {
+		normalized := normalizeRecoveryCode(" " + strings.ToUpper(code) + " ")
+		b, err := recoveryCodeEncoding.DecodeString(strings.ToUpper(normalized))
+		if err != nil || len(b)*8 < 80 || len(code) != 19 {
+			t.Errorf("code %q holds %d bits, want a 19 character code of 80 bits", code, len(b)*8)
+		}
+		if hashToken(normalized) != hashes[i] {
+			t.Errorf("the hash of %q does not match the code as typed back", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q is repeated", code)
+		}
+		seen[code] = true
+	}
+}
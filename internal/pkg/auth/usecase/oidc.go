@@ -0,0 +1,245 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/audit"
+	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/utils/hasher"
+	"MovieService/internal/pkg/utils/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultOIDCStateTTL = 10 * time.Minute
+	// accounts created on first SSO login whose IdP username does not fit
+	// the login rules are named sso_<random hex>
+	ssoLoginPrefix = "sso_"
+)
+
+type OIDCConfig struct {
+	// AdminGroup, when set, makes the IdP the source of the admin role: users
+	// in the group are admins, everyone else is not. Empty leaves the role
+	// alone.
+	AdminGroup string
+	StateTTL   time.Duration
+}
+
+type OIDCUsecase struct {
+	repo     auth.AuthRepo
+	provider auth.OIDCProvider
+	sessions auth.AuthUsecase
+	audit    audit.Recorder
+	cfg      OIDCConfig
+}
+
+func NewOIDCUsecase(repo auth.AuthRepo, provider auth.OIDCProvider, sessions auth.AuthUsecase, recorder audit.Recorder,
+	cfg OIDCConfig) *OIDCUsecase {
+	if cfg.StateTTL <= 0 {
+		cfg.StateTTL = DefaultOIDCStateTTL
+	}
+
+	return &OIDCUsecase{
+		repo:     repo,
+		provider: provider,
+		sessions: sessions,
+		audit:    recorder,
+		cfg:      cfg,
+	}
+}
+
+// Begin starts an authorization request and returns the URL of the IdP and
+// the state the browser has to come back with. A non-zero linkUserId links
+// the identity to that account instead of signing in.
+func (ou *OIDCUsecase) Begin(ctx context.Context, linkUserId int) (string, string, error) {
+	state, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	pending := &models.OIDCState{UserId: linkUserId}
+	if pending.Nonce, err = randomToken(); err != nil {
+		return "", "", err
+	}
+	if pending.Verifier, err = randomToken(); err != nil {
+		return "", "", err
+	}
+
+	err = ou.repo.CreateOIDCState(ctx, hashToken(state), pending, time.Now().Add(ou.cfg.StateTTL))
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := ou.provider.AuthCodeURL(ctx, state, pending.Nonce, pending.Verifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, state, nil
+}
+
+// Complete redeems the code, finds or creates the account of the identity
+// and starts a session for it. Users with two-factor authentication get a
+// challenge instead, completed like the one of a password sign-in.
+func (ou *OIDCUsecase) Complete(ctx context.Context, state string, code string, device string) (*models.TokenPair, *models.MFAChallenge, error) {
+	pending, err := ou.repo.TakeOIDCState(ctx, hashToken(state))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	identity, err := ou.provider.Exchange(ctx, code, pending.Verifier, pending.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var user *models.User
+	if pending.UserId != 0 {
+		user, err = ou.link(ctx, pending.UserId, identity)
+	} else {
+		user, err = ou.findOrCreate(ctx, identity)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user.Disabled {
+		return nil, nil, auth.ErrUserDisabled
+	}
+
+	if ou.cfg.AdminGroup != "" {
+		isAdmin := slices.Contains(identity.Groups, ou.cfg.AdminGroup)
+		if isAdmin != user.IsAdmin {
+			if err = ou.repo.SetAdmin(ctx, user.Id, isAdmin); err != nil {
+				return nil, nil, err
+			}
+			logger.FromContext(ctx).Info("admin role set from group claim", "user", user.Id, "isAdmin", isAdmin)
+			before := *user
+			user.IsAdmin = isAdmin
+			action := "admin_grant"
+			if !isAdmin {
+				action = "admin_revoke"
+			}
+			ou.audit.Record(ctx, audit.Entry{
+				ActorId:  user.Id,
+				Action:   action,
+				Entity:   audit.EntityUser,
+				EntityId: user.Id,
+				Before:   before,
+				After:    user,
+			})
+
+			// like a demotion through the users API, it ends the sessions
+			// that still carry the role in their tokens
+			if !isAdmin {
+				if err = ou.repo.RevokeUserSessions(ctx, user.Id); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	challenge, err := ou.sessions.ChallengeMFA(ctx, user)
+	if err != nil || challenge != nil {
+		return nil, challenge, err
+	}
+
+	tokens, err := ou.sessions.StartSSOSession(ctx, user, device, identity.AuthTime)
+	return tokens, nil, err
+}
+
+func (ou *OIDCUsecase) link(ctx context.Context, userId int, identity *models.ExternalIdentity) (*models.User, error) {
+	user, err := ou.repo.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := ou.repo.GetUserByIdentity(ctx, identity.Issuer, identity.Subject)
+	if err == nil && linked.Id == user.Id {
+		return user, nil
+	}
+	if err == nil {
+		return nil, auth.ErrIdentityLinked
+	}
+	if !errors.Is(err, auth.ErrUserNotFound) {
+		return nil, err
+	}
+
+	if err = ou.repo.LinkIdentity(ctx, user.Id, identity.Issuer, identity.Subject); err != nil {
+		return nil, err
+	}
+
+	ou.recordLink(ctx, user.Id, identity)
+	return user, nil
+}
+
+func (ou *OIDCUsecase) findOrCreate(ctx context.Context, identity *models.ExternalIdentity) (*models.User, error) {
+	user, err := ou.repo.GetUserByIdentity(ctx, identity.Issuer, identity.Subject)
+	if !errors.Is(err, auth.ErrUserNotFound) {
+		return user, err
+	}
+
+	// SSO accounts have an unusable password, so they cannot sign in locally
+	user = &models.User{Login: ssoLogin(identity), Password: hasher.Unusable("sso")}
+	user.Id, err = ou.repo.CreateUser(ctx, user)
+	if errors.Is(err, auth.ErrLoginTaken) {
+		if user.Login, err = randomSSOLogin(); err != nil {
+			return nil, err
+		}
+		user.Id, err = ou.repo.CreateUser(ctx, user)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ou.repo.LinkIdentity(ctx, user.Id, identity.Issuer, identity.Subject); err != nil {
+		return nil, err
+	}
+
+	u := *user
+	u.Password = ""
+	ou.audit.Record(ctx, audit.Entry{ActorId: user.Id, Action: "sign_up", Entity: audit.EntityUser, EntityId: user.Id, After: u})
+	ou.recordLink(ctx, user.Id, identity)
+	return user, nil
+}
+
+func (ou *OIDCUsecase) recordLink(ctx context.Context, userId int, identity *models.ExternalIdentity) {
+	ou.audit.Record(ctx, audit.Entry{
+		ActorId:  userId,
+		Action:   "link",
+		Entity:   audit.EntityIdentity,
+		EntityId: identity.Issuer + "#" + identity.Subject,
+		After:    identity,
+	})
+}
+
+// ssoLogin takes the login of a new account from the IdP username or the
+// local part of the email when it fits the login rules.
+func ssoLogin(identity *models.ExternalIdentity) string {
+	candidates := []string{identity.Username}
+	if local, _, ok := strings.Cut(identity.Email, "@"); ok {
+		candidates = append(candidates, local)
+	}
+
+	for _, login := range candidates {
+		if validateLogin(login) == "" {
+			return login
+		}
+	}
+
+	login, _ := randomSSOLogin()
+	return login
+}
+
+func randomSSOLogin() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return ssoLoginPrefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	authRepo "MovieService/internal/pkg/auth/repo"
+	"MovieService/internal/pkg/utils/hasher"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errSessionStarted = errors.New("session started")
+
+type oidcRepo struct {
+	*fakeRepo
+	users       map[string]*models.User
+	identities  map[string]int
+	totpEnabled bool
+	challenges  int
+	session     *models.Session
+}
+
+func newOIDCRepo() *oidcRepo {
+	return &oidcRepo{
+		fakeRepo:   newFakeRepo(),
+		users:      make(map[string]*models.User),
+		identities: make(map[string]int),
+	}
+}
+
+func (r *oidcRepo) TakeOIDCState(context.Context, string) (*models.OIDCState, error) {
+	return &models.OIDCState{Nonce: "nonce", Verifier: "verifier"}, nil
+}
+
+func (r *oidcRepo) GetUserByIdentity(_ context.Context, issuer string, subject string) (*models.User, error) {
+	id, ok := r.identities[issuer+"#"+subject]
+	if !ok {
+		return nil, auth.ErrUserNotFound
+	}
+
+	return r.GetUserById(context.Background(), id)
+}
+
+func (r *oidcRepo) GetUserById(_ context.Context, id int) (*models.User, error) {
+	for _, u := range r.users {
+		if u.Id == id {
+			user := *u
+			return &user, nil
+		}
+	}
+
+	return nil, auth.ErrUserNotFound
+}
+
+func (r *oidcRepo) GetUserByLogin(_ context.Context, login string) (*models.User, error) {
+	u, ok := r.users[login]
+	if !ok {
+		return nil, auth.ErrUserNotFound
+	}
+
+	user := *u
+	return &user, nil
+}
+
+func (r *oidcRepo) CreateUser(_ context.Context, user *models.User) (int, error) {
+	if _, ok := r.users[user.Login]; ok {
+		return 0, auth.ErrLoginTaken
+	}
+
+	u := *user
+	u.Id = len(r.users) + 1
+	r.users[u.Login] = &u
+	return u.Id, nil
+}
+
+func (r *oidcRepo) LinkIdentity(_ context.Context, userId int, issuer string, subject string) error {
+	r.identities[issuer+"#"+subject] = userId
+	return nil
+}
+
+func (r *oidcRepo) SetAdmin(_ context.Context, id int, isAdmin bool) error {
+	for _, u := range r.users {
+		if u.Id == id {
+			u.IsAdmin = isAdmin
+		}
+	}
+
+	return nil
+}
+
+func (r *oidcRepo) GetTOTP(_ context.Context, userId int) (*models.TOTP, error) {
+	if !r.totpEnabled {
+		return nil, auth.ErrTOTPNotEnrolled
+	}
+
+	enabledAt := time.Now()
+	return &models.TOTP{UserId: userId, EnabledAt: &enabledAt}, nil
+}
+
+func (r *oidcRepo) CreateMFAChallenge(context.Context, string, int, time.Time) error {
+	r.challenges++
+	return nil
+}
+
+// CreateSession stops StartSession before tokens are signed, the tests only
+// need to know that a session was started.
+func (r *oidcRepo) CreateSession(_ context.Context, session *models.Session) (int, error) {
+	r.session = session
+	return 0, errSessionStarted
+}
+
+type fakeProvider struct {
+	identity *models.ExternalIdentity
+}
+
+func (p fakeProvider) AuthCodeURL(context.Context, string, string, string) (string, error) {
+	return "https://idp.example/authorize", nil
+}
+
+func (p fakeProvider) Exchange(context.Context, string, string, string) (*models.ExternalIdentity, error) {
+	return p.identity, nil
+}
+
+// newTestAuthUsecase uses a cheap hasher and attempts kept in memory.
+func newTestAuthUsecase(t *testing.T, repo auth.AuthRepo) *AuthUsecase {
+	t.Helper()
+
+	h, err := hasher.NewArgon2Hasher(hasher.Params{Memory: 64, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32})
+	if err != nil {
+		t.Fatalf("NewArgon2Hasher: %v", err)
+	}
+
+	return NewAuthUsecase(repo, authRepo.NewMemoryAttemptStore(), h, nil, nopRecorder{}, DefaultConfig)
+}
+
+func newTestUsecases(t *testing.T, repo *oidcRepo) (*AuthUsecase, *OIDCUsecase) {
+	t.Helper()
+
+	au := newTestAuthUsecase(t, repo)
+	provider := fakeProvider{identity: &models.ExternalIdentity{Issuer: "https://idp.example", Subject: "42", Username: "alice"}}
+	ou := NewOIDCUsecase(repo, provider, au, nopRecorder{}, OIDCConfig{})
+
+	return au, ou
+}
+
+func TestOIDCCompleteAsksForTheSecondFactor(t *testing.T) {
+	tests := []struct {
+		name          string
+		totpEnabled   bool
+		wantChallenge bool
+		wantErr       error
+	}{
+		{"without two-factor authentication", false, false, errSessionStarted},
+		{"with two-factor authentication", true, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newOIDCRepo()
+			repo.totpEnabled = tt.totpEnabled
+			_, ou := newTestUsecases(t, repo)
+
+			tokens, challenge, err := ou.Complete(context.Background(), "state", "code", "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Complete error = %v, want %v", err, tt.wantErr)
+			}
+			if tokens != nil {
+				t.Errorf("Complete returned tokens, want none")
+			}
+			if (challenge != nil) != tt.wantChallenge || (repo.challenges == 1) != tt.wantChallenge {
+				t.Errorf("challenge = %+v with %d stored, want a challenge: %v", challenge, repo.challenges, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestSSOAccountCannotSignInWithPassword(t *testing.T) {
+	repo := newOIDCRepo()
+	_, ou := newTestUsecases(t, repo)
+
+	if _, _, err := ou.Complete(context.Background(), "state", "code", "test"); !errors.Is(err, errSessionStarted) {
+		t.Fatalf("Complete error = %v, want a started session", err)
+	}
+
+	created := repo.users["alice"]
+	if created == nil {
+		t.Fatalf("no account was created for the identity")
+	}
+	if hasher.Usable(created.Password) {
+		t.Errorf("SSO account has a usable password %q", created.Password)
+	}
+
+	for _, password := range []string{"", created.Password} {
+		// a fresh attempt store each time, so that the first failure does
+		// not lock the second attempt out
+		au, _ := newTestUsecases(t, repo)
+		user := &models.User{Login: "alice", Password: password}
+		if _, err := au.SignIn(context.Background(), user, "192.0.2.1"); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Errorf("SignIn with password %q = %v, want ErrInvalidCredentials", password, err)
+		}
+	}
+}
+
+func TestOIDCAdminGroupSync(t *testing.T) {
+	tests := []struct {
+		name        string
+		isAdmin     bool
+		groups      []string
+		wantAdmin   bool
+		wantRevoked bool
+	}{
+		{"demotion ends the other sessions", true, []string{"users"}, false, true},
+		{"promotion keeps them", false, []string{"users", "admins"}, true, false},
+		{"unchanged role keeps them", true, []string{"admins"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newOIDCRepo()
+			repo.users["alice"] = &models.User{Id: 1, Login: "alice", IsAdmin: tt.isAdmin}
+			repo.identities["https://idp.example#42"] = 1
+			repo.sessions[7] = &fakeSession{userId: 1, accessJti: "other"}
+
+			au := newTestAuthUsecase(t, repo)
+			provider := fakeProvider{identity: &models.ExternalIdentity{Issuer: "https://idp.example", Subject: "42", Groups: tt.groups}}
+			ou := NewOIDCUsecase(repo, provider, au, nopRecorder{}, OIDCConfig{AdminGroup: "admins"})
+
+			if _, _, err := ou.Complete(context.Background(), "state", "code", "test"); !errors.Is(err, errSessionStarted) {
+				t.Fatalf("Complete error = %v, want a started session", err)
+			}
+
+			if got := repo.users["alice"].IsAdmin; got != tt.wantAdmin {
+				t.Errorf("IsAdmin = %v, want %v", got, tt.wantAdmin)
+			}
+			if got := repo.sessions[7].revoked; got != tt.wantRevoked {
+				t.Errorf("other session revoked = %v, want %v", got, tt.wantRevoked)
+			}
+
+			err := au.CheckToken(context.Background(), claims("other", 1, 7))
+			if tt.wantRevoked && !errors.Is(err, auth.ErrTokenRevoked) {
+				t.Errorf("CheckToken of the other session = %v, want ErrTokenRevoked", err)
+			}
+		})
+	}
+}
+
+func TestOIDCSessionKeepsTheAuthTime(t *testing.T) {
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	repo := newOIDCRepo()
+	au := newTestAuthUsecase(t, repo)
+	provider := fakeProvider{identity: &models.ExternalIdentity{Issuer: "https://idp.example", Subject: "42", AuthTime: authTime}}
+	ou := NewOIDCUsecase(repo, provider, au, nopRecorder{}, OIDCConfig{})
+
+	if _, _, err := ou.Complete(context.Background(), "state", "code", "test"); !errors.Is(err, errSessionStarted) {
+		t.Fatalf("Complete error = %v, want a started session", err)
+	}
+	if repo.session == nil || !repo.session.AuthTime.Equal(authTime) {
+		t.Errorf("session = %+v, want the auth time %v of the issuer", repo.session, authTime)
+	}
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/audit"
+	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/utils/logger"
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+type ThrottleConfig struct {
+	// LoginThreshold and IPThreshold are the numbers of failures after which
+	// the login or the address is locked out for LockoutDuration.
+	LoginThreshold  int
+	IPThreshold     int
+	LockoutDuration time.Duration
+	// Below the threshold every failure for a login delays the next attempt
+	// by BaseDelay * 2^(failures-1), capped at MaxDelay. Addresses are only
+	// locked out, since many users may share one.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window is how long failures are remembered.
+	Window time.Duration
+}
+
+var DefaultThrottleConfig = ThrottleConfig{
+	LoginThreshold:  10,
+	IPThreshold:     50,
+	LockoutDuration: 15 * time.Minute,
+	BaseDelay:       time.Second,
+	MaxDelay:        30 * time.Second,
+	Window:          time.Hour,
+}
+
+const (
+	loginKeyPrefix = "login:"
+	ipKeyPrefix    = "ip:"
+)
+
+func loginKey(login string) string {
+	return loginKeyPrefix + login
+}
+
+func ipKey(ip string) string {
+	return ipKeyPrefix + ip
+}
+
+func (au *AuthUsecase) checkLockout(ctx context.Context, keys []string) error {
+	var retryAfter time.Duration
+	for _, key := range keys {
+		attempts, err := au.attempts.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if attempts.LockedUntil != nil {
+			if wait := time.Until(*attempts.LockedUntil); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return &auth.LockedError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// registerFailure counts the failed attempt for every key and returns the
+// error the caller should answer with.
+func (au *AuthUsecase) registerFailure(ctx context.Context, keys []string) error {
+	cfg := au.cfg.Throttle
+	for _, key := range keys {
+		attempts, err := au.attempts.AddFailure(ctx, key, cfg.Window)
+		if err != nil {
+			return err
+		}
+
+		threshold, delay := cfg.LoginThreshold, backoff(cfg, attempts.Failures)
+		if strings.HasPrefix(key, ipKeyPrefix) {
+			threshold, delay = cfg.IPThreshold, 0
+		}
+
+		if attempts.Failures >= threshold {
+			delay = cfg.LockoutDuration
+			logger.FromContext(ctx).Warn("sign-in locked", "key", key, "failures", attempts.Failures)
+		}
+
+		if delay > 0 {
+			if err = au.attempts.Lock(ctx, key, time.Now().Add(delay)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return auth.ErrInvalidCredentials
+}
+
+// throttle runs check, the check of a password or a code given by a
+// signed-in user, under the lockout of the login. Wrong answers count as
+// failed sign-ins, so that an access token cannot be used to guess them.
+func (au *AuthUsecase) throttle(ctx context.Context, login string, check func() error) error {
+	keys := []string{loginKey(login)}
+	if err := au.checkLockout(ctx, keys); err != nil {
+		return err
+	}
+
+	err := check()
+	if errors.Is(err, auth.ErrInvalidOTP) || errors.Is(err, auth.ErrInvalidCredentials) {
+		if lockErr := au.registerFailure(ctx, keys); !errors.Is(lockErr, auth.ErrInvalidCredentials) {
+			return lockErr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	return au.attempts.Reset(ctx, keys[0])
+}
+
+func backoff(cfg ThrottleConfig, failures int) time.Duration {
+	if failures < 1 || cfg.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := cfg.BaseDelay
+	for i := 1; i < failures && delay < cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > cfg.MaxDelay {
+		delay = cfg.MaxDelay
+	}
+
+	return delay
+}
+
+func (au *AuthUsecase) ListLockouts(ctx context.Context) ([]models.LoginAttempts, error) {
+	return au.attempts.ListLocked(ctx)
+}
+
+func (au *AuthUsecase) Unlock(ctx context.Context, key string) error {
+	if err := au.attempts.Reset(ctx, key); err != nil {
+		return err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "unlock", Entity: audit.EntityLockout, EntityId: key})
+	return nil
+}
@@ -2,35 +2,380 @@ package usecase
 
 import (
 	"MovieService/internal/models"
+	"MovieService/internal/pkg/apperr"
+	"MovieService/internal/pkg/audit"
 	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/utils/hasher"
+	"MovieService/internal/pkg/utils/jwt"
+	"MovieService/internal/pkg/utils/logger"
 	"context"
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
 )
 
+const (
+	DefaultRefreshTTL   = 30 * 24 * time.Hour
+	DefaultReauthWindow = 5 * time.Minute
+)
+
+type Config struct {
+	RefreshTTL     time.Duration
+	PasswordPolicy PasswordPolicy
+	Throttle       ThrottleConfig
+	MFA            MFAConfig
+	// ReauthWindow is how recent the sign-in of a session must be to
+	// confirm changes to an account without a password.
+	ReauthWindow time.Duration
+}
+
+var DefaultConfig = Config{
+	RefreshTTL:     DefaultRefreshTTL,
+	PasswordPolicy: DefaultPasswordPolicy,
+	Throttle:       DefaultThrottleConfig,
+	MFA:            DefaultMFAConfig,
+	ReauthWindow:   DefaultReauthWindow,
+}
+
 type AuthUsecase struct {
-	repo auth.AuthRepo
+	repo     auth.AuthRepo
+	attempts auth.AttemptStore
+	hasher   hasher.PasswordHasher
+	tm       jwt.TokenManager
+	audit    audit.Recorder
+	cfg      Config
+	// dummyHash is verified against when the login is unknown so that the
+	// response time does not reveal which logins exist.
+	dummyHash string
 }
 
-func NewAuthUsecase(repo auth.AuthRepo) *AuthUsecase {
+func NewAuthUsecase(repo auth.AuthRepo, attempts auth.AttemptStore, hasher hasher.PasswordHasher, tm jwt.TokenManager,
+	recorder audit.Recorder, cfg Config) *AuthUsecase {
+	dummyHash, _ := hasher.Hash("dummy password")
+
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = DefaultRefreshTTL
+	}
+
+	if cfg.MFA == (MFAConfig{}) {
+		cfg.MFA = DefaultMFAConfig
+	}
+
+	if cfg.ReauthWindow <= 0 {
+		cfg.ReauthWindow = DefaultReauthWindow
+	}
+
 	return &AuthUsecase{
-		repo: repo,
+		repo:      repo,
+		attempts:  attempts,
+		hasher:    hasher,
+		tm:        tm,
+		audit:     recorder,
+		cfg:       cfg,
+		dummyHash: dummyHash,
 	}
 }
 
-func (au *AuthUsecase) SignIn(ctx context.Context, user *models.User) error {
+// SignIn checks the credentials and fills user with the stored id and role.
+// Failed attempts are counted per login and per client address; once either
+// is locked out SignIn fails without looking at the password. For accounts
+// with two-factor authentication a challenge is returned, which has to be
+// completed with CompleteSignIn before a session is started.
+func (au *AuthUsecase) SignIn(ctx context.Context, user *models.User, ip string) (*models.MFAChallenge, error) {
+	keys := []string{loginKey(user.Login), ipKey(ip)}
+	if err := au.checkLockout(ctx, keys); err != nil {
+		au.recordSignInFailure(ctx, "sign_in_locked", user.Login, err)
+		return nil, err
+	}
+
 	u, err := au.repo.GetUserByLogin(ctx, user.Login)
+	if errors.Is(err, auth.ErrUserNotFound) {
+		_, _, _ = au.hasher.Verify(user.Password, au.dummyHash)
+		au.recordSignInFailure(ctx, "sign_in_failed", user.Login, auth.ErrUserNotFound)
+		return nil, au.registerFailure(ctx, keys)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if u.Password == user.Password {
-		return nil
+	// accounts waiting for a reset or signing in through SSO only have no
+	// password to check, they fail like a wrong password
+	if !hasher.Usable(u.Password) {
+		_, _, _ = au.hasher.Verify(user.Password, au.dummyHash)
+		au.recordSignInFailure(ctx, "sign_in_failed", user.Login, auth.ErrInvalidCredentials)
+		return nil, au.registerFailure(ctx, keys)
 	}
 
-	return fmt.Errorf("forbidden")
+	match, needsRehash, err := au.hasher.Verify(user.Password, u.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		au.recordSignInFailure(ctx, "sign_in_failed", user.Login, auth.ErrInvalidCredentials)
+		return nil, au.registerFailure(ctx, keys)
+	}
+
+	if err = au.attempts.Reset(ctx, loginKey(user.Login)); err != nil {
+		return nil, err
+	}
+
+	if u.Disabled {
+		au.recordSignInFailure(ctx, "sign_in_failed", user.Login, auth.ErrUserDisabled)
+		return nil, auth.ErrUserDisabled
+	}
+
+	if needsRehash {
+		au.rehash(ctx, u.Id, user.Password)
+	}
+
+	user.Id = u.Id
+	user.IsAdmin = u.IsAdmin
+	user.Password = ""
+
+	return au.ChallengeMFA(ctx, user)
+}
+
+// rehash stores a hash of the password made with the current parameters. A
+// failure is only logged, the password was right and the old hash still works.
+func (au *AuthUsecase) rehash(ctx context.Context, userId int, password string) {
+	hash, err := au.hasher.Hash(password)
+	if err == nil {
+		err = au.repo.UpdatePassword(ctx, userId, hash)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to rehash password", "user", userId, "error", err)
+	}
 }
 
+// SignUp registers a client account. Admin rights are never taken from the
+// request, they are granted through the users API only.
 func (au *AuthUsecase) SignUp(ctx context.Context, user *models.User) (int, error) {
-	id, err := au.repo.CreateUser(ctx, user)
-	return id, err
+	fields := make(map[string]string)
+	if msg := validateLogin(user.Login); msg != "" {
+		fields["login"] = msg
+	}
+
+	if msg := au.cfg.PasswordPolicy.validate(user.Password); msg != "" {
+		fields["password"] = msg
+	}
+
+	if len(fields) != 0 {
+		return 0, apperr.Validation(fields)
+	}
+
+	hash, err := au.hasher.Hash(user.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	u := *user
+	u.Password = hash
+	u.IsAdmin = false
+	user.IsAdmin = false
+	id, err := au.repo.CreateUser(ctx, &u)
+	if err != nil {
+		return 0, err
+	}
+
+	u.Id = id
+	au.audit.Record(ctx, audit.Entry{ActorId: id, Action: "sign_up", Entity: audit.EntityUser, EntityId: id, After: u})
+	return id, nil
+}
+
+// StartSession opens a new session for the signed-in user on the given device
+// and issues the first access/refresh pair of it.
+func (au *AuthUsecase) StartSession(ctx context.Context, user *models.User, device string) (*models.TokenPair, error) {
+	return au.startSession(ctx, user, device, false, time.Now())
+}
+
+// StartSSOSession opens a session for a user signed in through the issuer,
+// who logged in there at authTime.
+func (au *AuthUsecase) StartSSOSession(ctx context.Context, user *models.User, device string, authTime time.Time) (*models.TokenPair, error) {
+	return au.startSession(ctx, user, device, false, authTime)
+}
+
+func (au *AuthUsecase) startSession(ctx context.Context, user *models.User, device string, mfa bool, authTime time.Time) (*models.TokenPair, error) {
+	session := &models.Session{UserId: user.Id, Device: device, Mfa: mfa, AuthTime: authTime}
+
+	var err error
+	session.Id, err = au.repo.CreateSession(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	au.audit.Record(ctx, audit.Entry{
+		ActorId:  user.Id,
+		Action:   "sign_in",
+		Entity:   audit.EntitySession,
+		EntityId: session.Id,
+		After:    session,
+	})
+
+	return au.issueTokens(ctx, user, session)
+}
+
+// Refresh exchanges a refresh token for a new pair. Every refresh token can be
+// used only once; presenting a used one again revokes the whole session.
+func (au *AuthUsecase) Refresh(ctx context.Context, refreshToken string, device string) (*models.TokenPair, error) {
+	token, err := au.repo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := au.repo.GetSession(ctx, token.SessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.RevokedAt != nil {
+		return nil, auth.ErrInvalidRefreshToken
+	}
+
+	if token.UsedAt != nil {
+		return nil, au.revokeReused(ctx, session)
+	}
+
+	if time.Now().After(token.ExpiresAt) || session.Device != device {
+		return nil, auth.ErrInvalidRefreshToken
+	}
+
+	ok, err := au.repo.UseRefreshToken(ctx, token.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, au.revokeReused(ctx, session)
+	}
+
+	user, err := au.repo.GetUserById(ctx, session.UserId)
+	if errors.Is(err, auth.ErrUserNotFound) {
+		return nil, auth.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Disabled {
+		return nil, auth.ErrUserDisabled
+	}
+
+	return au.issueTokens(ctx, user, session)
+}
+
+func (au *AuthUsecase) revokeReused(ctx context.Context, session *models.Session) error {
+	if err := au.repo.RevokeSession(ctx, session.Id); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Warn("refresh token reuse detected, session revoked", "session", session.Id, "user", session.UserId)
+	au.audit.Record(ctx, audit.Entry{
+		ActorId:  session.UserId,
+		Action:   "refresh_token_reused",
+		Entity:   audit.EntitySession,
+		EntityId: session.Id,
+	})
+	return auth.ErrRefreshTokenReused
+}
+
+// Logout ends the session the access token belongs to and revokes the token.
+func (au *AuthUsecase) Logout(ctx context.Context, claims *models.JwtClaims) error {
+	if claims.SessionId != 0 {
+		if err := au.repo.RevokeSession(ctx, claims.SessionId); err != nil {
+			return err
+		}
+	}
+
+	if err := au.repo.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "logout", Entity: audit.EntitySession, EntityId: claims.SessionId})
+	return nil
+}
+
+// LogoutAll ends every session of the user, including the current one.
+func (au *AuthUsecase) LogoutAll(ctx context.Context, claims *models.JwtClaims) error {
+	if err := au.repo.RevokeUserSessions(ctx, claims.UserId); err != nil {
+		return err
+	}
+
+	if err := au.repo.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "logout_all", Entity: audit.EntityUser, EntityId: claims.UserId})
+	return nil
+}
+
+// CheckToken reports whether an access token that passed signature and expiry
+// validation has been revoked since, along with its session, or belongs to a
+// disabled user.
+func (au *AuthUsecase) CheckToken(ctx context.Context, claims *models.JwtClaims) error {
+	revoked, disabled, err := au.repo.GetTokenStatus(ctx, claims.Id, claims.UserId, claims.SessionId)
+	if err != nil {
+		return err
+	}
+
+	if revoked {
+		return auth.ErrTokenRevoked
+	}
+
+	if disabled {
+		return auth.ErrUserDisabled
+	}
+
+	return nil
+}
+
+func (au *AuthUsecase) issueTokens(ctx context.Context, user *models.User, session *models.Session) (*models.TokenPair, error) {
+	sessionId := session.Id
+	claims := &models.JwtClaims{
+		UserId:    user.Id,
+		IsAdmin:   user.IsAdmin,
+		SessionId: sessionId,
+		Mfa:       session.Mfa,
+	}
+	if !session.AuthTime.IsZero() {
+		claims.AuthTime = session.AuthTime.Unix()
+	}
+
+	accessToken, err := au.tm.NewJWT(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	err = au.repo.SetSessionAccessToken(ctx, sessionId, claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := au.tm.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshExpiresAt := time.Now().Add(au.cfg.RefreshTTL)
+	err = au.repo.CreateRefreshToken(ctx, &models.RefreshToken{
+		SessionId: sessionId,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: refreshExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:      accessToken,
+		ExpiresAt:        time.Unix(claims.ExpiresAt, 0),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// Refresh tokens are random 256-bit values, so a fast hash is enough to keep
+// them useless if the table leaks.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/audit"
+	"MovieService/internal/pkg/auth"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type nopRecorder struct{}
+
+func (nopRecorder) Record(context.Context, audit.Entry) {}
+
+type fakeSession struct {
+	userId    int
+	accessJti string
+	revoked   bool
+}
+
+// fakeRepo keeps the sessions and the denylist in memory, the way the
+// postgres queries treat them. Methods the tests do not use panic through the
+// nil embedded interface.
+type fakeRepo struct {
+	auth.AuthRepo
+	sessions map[int]*fakeSession
+	revoked  map[string]bool
+	disabled map[int]bool
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		sessions: make(map[int]*fakeSession),
+		revoked:  make(map[string]bool),
+		disabled: make(map[int]bool),
+	}
+}
+
+func (r *fakeRepo) RevokeSession(_ context.Context, id int) error {
+	if s, ok := r.sessions[id]; ok && !s.revoked {
+		r.revoked[s.accessJti] = true
+		s.revoked = true
+	}
+
+	return nil
+}
+
+func (r *fakeRepo) RevokeUserSessions(_ context.Context, userId int) error {
+	for _, s := range r.sessions {
+		if s.userId == userId && !s.revoked {
+			r.revoked[s.accessJti] = true
+			s.revoked = true
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeRepo) RevokeToken(_ context.Context, jti string, _ time.Time) error {
+	r.revoked[jti] = true
+	return nil
+}
+
+func (r *fakeRepo) GetTokenStatus(_ context.Context, jti string, userId int, sessionId int) (bool, bool, error) {
+	revoked := r.revoked[jti]
+	if sessionId != 0 {
+		s, ok := r.sessions[sessionId]
+		revoked = revoked || !ok || s.userId != userId || s.revoked
+	}
+
+	return revoked, r.disabled[userId], nil
+}
+
+func claims(jti string, userId int, sessionId int) *models.JwtClaims {
+	c := &models.JwtClaims{UserId: userId, SessionId: sessionId}
+	c.Id = jti
+	c.ExpiresAt = time.Now().Add(time.Minute).Unix()
+	return c
+}
+
+func TestCheckTokenAfterLogout(t *testing.T) {
+	tests := []struct {
+		name   string
+		logout func(*AuthUsecase) error
+		token  *models.JwtClaims
+		want   error
+	}{
+		{
+			name:   "latest token of the logged out session",
+			logout: func(au *AuthUsecase) error { return au.Logout(context.Background(), claims("latest", 1, 10)) },
+			token:  claims("latest", 1, 10),
+			want:   auth.ErrTokenRevoked,
+		},
+		{
+			name:   "token issued before a refresh of the logged out session",
+			logout: func(au *AuthUsecase) error { return au.Logout(context.Background(), claims("latest", 1, 10)) },
+			token:  claims("before-refresh", 1, 10),
+			want:   auth.ErrTokenRevoked,
+		},
+		{
+			name:   "other session after logout",
+			logout: func(au *AuthUsecase) error { return au.Logout(context.Background(), claims("latest", 1, 10)) },
+			token:  claims("other", 1, 11),
+			want:   nil,
+		},
+		{
+			name:   "token issued before a refresh of another session after logout everywhere",
+			logout: func(au *AuthUsecase) error { return au.LogoutAll(context.Background(), claims("latest", 1, 10)) },
+			token:  claims("before-refresh", 1, 11),
+			want:   auth.ErrTokenRevoked,
+		},
+		{
+			name:   "session of another user after logout everywhere",
+			logout: func(au *AuthUsecase) error { return au.LogoutAll(context.Background(), claims("latest", 1, 10)) },
+			token:  claims("stranger", 2, 12),
+			want:   nil,
+		},
+		{
+			name:   "token naming a session of another user",
+			logout: func(*AuthUsecase) error { return nil },
+			token:  claims("forged", 2, 10),
+			want:   auth.ErrTokenRevoked,
+		},
+		{
+			name:   "token naming a deleted session",
+			logout: func(*AuthUsecase) error { return nil },
+			token:  claims("gone", 1, 99),
+			want:   auth.ErrTokenRevoked,
+		},
+		{
+			name:   "token without a session",
+			logout: func(au *AuthUsecase) error { return au.LogoutAll(context.Background(), claims("latest", 1, 10)) },
+			token:  claims("sessionless", 1, 0),
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.sessions[10] = &fakeSession{userId: 1, accessJti: "latest"}
+			repo.sessions[11] = &fakeSession{userId: 1, accessJti: "other"}
+			repo.sessions[12] = &fakeSession{userId: 2, accessJti: "stranger"}
+			au := &AuthUsecase{repo: repo, audit: nopRecorder{}}
+
+			if err := tt.logout(au); err != nil {
+				t.Fatalf("logout: %v", err)
+			}
+
+			if err := au.CheckToken(context.Background(), tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("CheckToken = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTokenDisabledUser(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions[10] = &fakeSession{userId: 1, accessJti: "latest"}
+	repo.disabled[1] = true
+	au := &AuthUsecase{repo: repo, audit: nopRecorder{}}
+
+	if err := au.CheckToken(context.Background(), claims("latest", 1, 10)); !errors.Is(err, auth.ErrUserDisabled) {
+		t.Errorf("CheckToken = %v, want ErrUserDisabled", err)
+	}
+}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/audit"
+	"MovieService/internal/pkg/utils/hasher"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+)
+
+const (
+	DefaultUsersLimit = 20
+	MaxUsersLimit     = 100
+
+	passwordResetTTL = 24 * time.Hour
+)
+
+func (au *AuthUsecase) ListUsers(ctx context.Context, page int, limit int) (*models.UserPage, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = DefaultUsersLimit
+	}
+
+	if limit > MaxUsersLimit {
+		limit = MaxUsersLimit
+	}
+
+	users, total, err := au.repo.ListUsers(ctx, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserPage{
+		Users: users,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+func (au *AuthUsecase) GetUser(ctx context.Context, id int) (*models.User, error) {
+	u, err := au.repo.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Password = ""
+	return u, nil
+}
+
+// SetAdmin grants or revokes the admin role. Revoking also ends the sessions
+// of the user, so tokens with the old role stop working right away.
+func (au *AuthUsecase) SetAdmin(ctx context.Context, id int, isAdmin bool) error {
+	before, err := au.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = au.repo.SetAdmin(ctx, id, isAdmin); err != nil {
+		return err
+	}
+
+	after := *before
+	after.IsAdmin = isAdmin
+	action := "admin_grant"
+	if !isAdmin {
+		action = "admin_revoke"
+	}
+	au.audit.Record(ctx, audit.Entry{Action: action, Entity: audit.EntityUser, EntityId: id, Before: before, After: after})
+
+	if isAdmin {
+		return nil
+	}
+
+	return au.repo.RevokeUserSessions(ctx, id)
+}
+
+func (au *AuthUsecase) SetDisabled(ctx context.Context, id int, disabled bool) error {
+	before, err := au.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = au.repo.SetDisabled(ctx, id, disabled); err != nil {
+		return err
+	}
+
+	after := *before
+	after.Disabled = disabled
+	action := "disable"
+	if !disabled {
+		action = "enable"
+	}
+	au.audit.Record(ctx, audit.Entry{Action: action, Entity: audit.EntityUser, EntityId: id, Before: before, After: after})
+
+	if !disabled {
+		return nil
+	}
+
+	return au.repo.RevokeUserSessions(ctx, id)
+}
+
+// ForcePasswordReset makes the password of the user unusable, ends their
+// sessions and returns a one-time token the user can set a new password with.
+func (au *AuthUsecase) ForcePasswordReset(ctx context.Context, id int) (*models.PasswordReset, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	reset := &models.PasswordReset{
+		ResetToken: token,
+		ExpiresAt:  time.Now().Add(passwordResetTTL),
+	}
+
+	if err = au.repo.SetPasswordReset(ctx, id, hasher.Unusable("password_reset"), hashToken(token), reset.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	if err = au.repo.RevokeUserSessions(ctx, id); err != nil {
+		return nil, err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "password_reset_forced", Entity: audit.EntityUser, EntityId: id})
+	return reset, nil
+}
+
+func (au *AuthUsecase) ResetPassword(ctx context.Context, token string, password string) error {
+	if err := au.checkPasswordPolicy("password", password); err != nil {
+		return err
+	}
+
+	hash, err := au.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	id, err := au.repo.ResetPassword(ctx, hashToken(token), hash)
+	if err != nil {
+		return err
+	}
+
+	au.audit.Record(ctx, audit.Entry{ActorId: id, Action: "password_reset", Entity: audit.EntityUser, EntityId: id})
+	return nil
+}
+
+// MigrateLegacyPasswords hashes the plaintext passwords left from before
+// passwords were hashed, so that sign-in never compares plaintext. It returns
+// how many were hashed and is meant to run once at startup.
+func (au *AuthUsecase) MigrateLegacyPasswords(ctx context.Context) (int, error) {
+	legacy, err := au.repo.GetLegacyPasswords(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for id, password := range legacy {
+		hash, err := au.hasher.Hash(password)
+		if err != nil {
+			return 0, err
+		}
+
+		if err = au.repo.MigratePassword(ctx, id, password, hash); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(legacy), nil
+}
+
+func (au *AuthUsecase) DeleteUser(ctx context.Context, id int) error {
+	before, err := au.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = au.repo.RevokeUserSessions(ctx, id); err != nil {
+		return err
+	}
+
+	if err = au.repo.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+
+	au.audit.Record(ctx, audit.Entry{Action: "delete", Entity: audit.EntityUser, EntityId: id, Before: before})
+	return nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"MovieService/internal/pkg/utils/hasher"
+	"context"
+	"testing"
+	"time"
+)
+
+type resetRepo struct {
+	*fakeRepo
+	password string
+}
+
+func (r *resetRepo) SetPasswordReset(_ context.Context, _ int, unusable string, _ string, _ time.Time) error {
+	r.password = unusable
+	return nil
+}
+
+func TestForcePasswordResetLeavesNoUsablePassword(t *testing.T) {
+	h, err := hasher.NewArgon2Hasher(hasher.Params{Memory: 64, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32})
+	if err != nil {
+		t.Fatalf("NewArgon2Hasher: %v", err)
+	}
+
+	repo := &resetRepo{fakeRepo: newFakeRepo()}
+	au := &AuthUsecase{repo: repo, hasher: h, audit: nopRecorder{}}
+
+	if _, err = au.ForcePasswordReset(context.Background(), 1); err != nil {
+		t.Fatalf("ForcePasswordReset: %v", err)
+	}
+
+	for _, password := range []string{"", repo.password} {
+		if match, _, _ := h.Verify(password, repo.password); match {
+			t.Errorf("password %q matches the stored value %q after a forced reset", password, repo.password)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"MovieService/internal/pkg/apperr"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	minLoginLength = 3
+	// the login column is varchar(16)
+	maxLoginLength = 16
+)
+
+var loginRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:    8,
+	MaxLength:    128,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+}
+
+func validateLogin(login string) string {
+	switch {
+	case len(login) < minLoginLength || len(login) > maxLoginLength:
+		return fmt.Sprintf("must be %d to %d characters long", minLoginLength, maxLoginLength)
+	case !loginRe.MatchString(login):
+		return "may contain only latin letters, digits, '_', '.' and '-'"
+	}
+
+	return ""
+}
+
+func (p PasswordPolicy) validate(password string) string {
+	length := len([]rune(password))
+	if length < p.MinLength {
+		return fmt.Sprintf("must be at least %d characters long", p.MinLength)
+	}
+
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Sprintf("must be at most %d characters long", p.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	missing := make([]string, 0)
+	if p.RequireUpper && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+
+	if len(missing) != 0 {
+		return "must contain " + strings.Join(missing, ", ")
+	}
+
+	return ""
+}
+
+// checkPasswordPolicy returns a validation error for the given field when the
+// password does not satisfy the policy.
+func (au *AuthUsecase) checkPasswordPolicy(field string, password string) error {
+	if msg := au.cfg.PasswordPolicy.validate(password); msg != "" {
+		return apperr.Validation(map[string]string{field: msg})
+	}
+
+	return nil
+}
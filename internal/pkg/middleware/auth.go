@@ -0,0 +1,268 @@
+package middleware
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/auth"
+	"MovieService/internal/pkg/policy"
+	"MovieService/internal/pkg/utils/jwt"
+	"MovieService/internal/pkg/utils/logger"
+	resp "MovieService/internal/pkg/utils/responser"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	AccessTokenCookie = "AccessToken"
+	ApiKeyHeader      = "X-API-Key"
+
+	jwtPrefix = "Bearer "
+	realm     = "MovieService"
+)
+
+// Error codes of RFC 6750, section 3.1.
+const (
+	errInvalidRequest    = "invalid_request"
+	errInvalidToken      = "invalid_token"
+	errInsufficientScope = "insufficient_scope"
+	// RFC 9470, the token is fine but the authentication behind it is not
+	// strong enough
+	errInsufficientAuth = "insufficient_user_authentication"
+)
+
+var (
+	errNoToken        = errors.New("no access token")
+	errMalformedToken = errors.New("malformed Authorization header")
+	errTwoCredentials = errors.New("both Authorization and X-API-Key headers are set")
+)
+
+type ctxKey int
+
+const (
+	claimsKey ctxKey = iota
+)
+
+type TokenChecker interface {
+	CheckToken(context.Context, *models.JwtClaims) error
+	CheckApiKey(context.Context, string) (*models.JwtClaims, error)
+}
+
+type AuthMiddleware struct {
+	tm      jwt.TokenManager
+	checker TokenChecker
+	policy  *policy.Policy
+}
+
+func NewAuthMiddleware(tm jwt.TokenManager, checker TokenChecker, policy *policy.Policy) *AuthMiddleware {
+	return &AuthMiddleware{
+		tm:      tm,
+		checker: checker,
+		policy:  policy,
+	}
+}
+
+// ClaimsFromContext returns the claims of the access token the request was
+// authenticated with.
+func ClaimsFromContext(ctx context.Context) (*models.JwtClaims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*models.JwtClaims)
+	return claims, ok
+}
+
+// Authenticate lets the request through when it carries a valid access token
+// and puts the claims of the token into the request context.
+func (am *AuthMiddleware) Authenticate(w http.ResponseWriter, r *http.Request, next func(w http.ResponseWriter, r *http.Request)) {
+	claims, ok := am.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	next(w, withClaims(r, claims))
+}
+
+// AuthenticateMFA is Authenticate for actions that must not be done with a
+// password alone when the role of the user requires a second factor.
+func (am *AuthMiddleware) AuthenticateMFA(w http.ResponseWriter, r *http.Request, next func(w http.ResponseWriter, r *http.Request)) {
+	claims, ok := am.authenticate(w, r)
+	if !ok || !am.checkMFA(w, r, claims) {
+		return
+	}
+
+	next(w, withClaims(r, claims))
+}
+
+// Authorize authenticates the request and checks the permission against the
+// policy for the role of the user.
+func (am *AuthMiddleware) Authorize(w http.ResponseWriter, r *http.Request, next func(w http.ResponseWriter, r *http.Request), perm policy.Permission) {
+	claims, ok := am.authenticate(w, r)
+	if !ok || !am.checkMFA(w, r, claims) {
+		return
+	}
+
+	role := policy.RoleOf(claims.IsAdmin)
+	allowed, reason := am.policy.Decide(role, perm)
+	if allowed && claims.ApiKeyId != 0 {
+		if _, ok := policy.Match(claims.Scopes, perm); !ok {
+			allowed, reason = false, fmt.Sprintf("API key %d is not scoped to %s", claims.ApiKeyId, perm)
+		}
+	}
+	if !allowed {
+		logger.FromContext(r.Context()).Info("access denied",
+			"user", claims.UserId, "permission", perm, "method", r.Method, "path", r.URL.Path, "reason", reason)
+		challenge(w, http.StatusForbidden, errInsufficientScope, reason)
+		return
+	}
+
+	logger.FromContext(r.Context()).Debug("access granted",
+		"user", claims.UserId, "permission", perm, "method", r.Method, "path", r.URL.Path, "reason", reason)
+	next(w, withClaims(r, claims))
+}
+
+// RequireAuth, RequireMFA and RequirePermission turn Authenticate,
+// AuthenticateMFA and Authorize into handlers for the route table.
+func (am *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		am.Authenticate(w, r, next)
+	}
+}
+
+func (am *AuthMiddleware) RequireMFA(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		am.AuthenticateMFA(w, r, next)
+	}
+}
+
+func (am *AuthMiddleware) RequirePermission(perm policy.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		am.Authorize(w, r, next, perm)
+	}
+}
+
+// checkMFA rejects sessions without a second factor for roles that need one.
+// API keys are exempt: they are created from such a session.
+func (am *AuthMiddleware) checkMFA(w http.ResponseWriter, r *http.Request, claims *models.JwtClaims) bool {
+	role := policy.RoleOf(claims.IsAdmin)
+	if claims.Mfa || claims.ApiKeyId != 0 || !am.policy.RequiresMFA(role) {
+		return true
+	}
+
+	logger.FromContext(r.Context()).Info("access denied",
+		"user", claims.UserId, "method", r.Method, "path", r.URL.Path, "reason", "role "+policy.RoleName(role)+" requires 2FA")
+	challenge(w, http.StatusUnauthorized, errInsufficientAuth, "two-factor authentication is required for this account")
+	return false
+}
+
+func (am *AuthMiddleware) authenticate(w http.ResponseWriter, r *http.Request) (*models.JwtClaims, bool) {
+	if key := r.Header.Get(ApiKeyHeader); key != "" {
+		if r.Header.Get("Authorization") != "" {
+			challenge(w, http.StatusBadRequest, errInvalidRequest, errTwoCredentials.Error())
+			return nil, false
+		}
+
+		return am.authenticateApiKey(w, r, key)
+	}
+
+	jwtStr, err := accessToken(r)
+	if errors.Is(err, errNoToken) {
+		challenge(w, http.StatusUnauthorized, "", "")
+		return nil, false
+	}
+	if err != nil {
+		challenge(w, http.StatusBadRequest, errInvalidRequest, err.Error())
+		return nil, false
+	}
+
+	claims, err := am.tm.Parse(jwtStr)
+	if err != nil {
+		logger.FromContext(r.Context()).Info("invalid access token", "path", r.URL.Path, "error", err)
+		challenge(w, http.StatusUnauthorized, errInvalidToken, "the access token is invalid or expired")
+		return nil, false
+	}
+
+	err = am.checker.CheckToken(r.Context(), claims)
+	if errors.Is(err, auth.ErrTokenRevoked) {
+		logger.FromContext(r.Context()).Info("revoked access token", "user", claims.UserId, "path", r.URL.Path)
+		challenge(w, http.StatusUnauthorized, errInvalidToken, "the access token has been revoked")
+		return nil, false
+	}
+	if errors.Is(err, auth.ErrUserDisabled) {
+		logger.FromContext(r.Context()).Info("access token of disabled user", "user", claims.UserId, "path", r.URL.Path)
+		challenge(w, http.StatusUnauthorized, errInvalidToken, "the account is disabled")
+		return nil, false
+	}
+	if err != nil {
+		logger.FromContext(r.Context()).Error("failed to check access token", "user", claims.UserId, "error", err)
+		resp.Fail(w, http.StatusInternalServerError, "internal server error")
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// authenticateApiKey checks a key of the X-API-Key header, which takes the
+// place of the access token for machine clients.
+func (am *AuthMiddleware) authenticateApiKey(w http.ResponseWriter, r *http.Request, key string) (*models.JwtClaims, bool) {
+	claims, err := am.checker.CheckApiKey(r.Context(), key)
+	if errors.Is(err, auth.ErrInvalidApiKey) {
+		logger.FromContext(r.Context()).Info("invalid API key", "path", r.URL.Path)
+		challenge(w, http.StatusUnauthorized, errInvalidToken, "the API key is invalid, expired or revoked")
+		return nil, false
+	}
+	if errors.Is(err, auth.ErrUserDisabled) {
+		logger.FromContext(r.Context()).Info("API key of disabled user", "path", r.URL.Path)
+		challenge(w, http.StatusUnauthorized, errInvalidToken, "the account is disabled")
+		return nil, false
+	}
+	if err != nil {
+		logger.FromContext(r.Context()).Error("failed to check API key", "error", err)
+		resp.Fail(w, http.StatusInternalServerError, "internal server error")
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// withClaims puts the claims into the request context and the user into the
+// request logger.
+func withClaims(r *http.Request, claims *models.JwtClaims) *http.Request {
+	ctx := context.WithValue(r.Context(), claimsKey, claims)
+	ctx = logger.WithContext(ctx, logger.FromContext(ctx).With("user", claims.UserId))
+	return r.WithContext(ctx)
+}
+
+// accessToken extracts the token from the Authorization header or, when the
+// header is absent, from the AccessToken cookie. A present but malformed
+// header is an error and never falls back to the cookie.
+func accessToken(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if len(header) <= len(jwtPrefix) || !strings.EqualFold(header[:len(jwtPrefix)], jwtPrefix) {
+			return "", errMalformedToken
+		}
+
+		return strings.TrimSpace(header[len(jwtPrefix):]), nil
+	}
+
+	cookie, err := r.Cookie(AccessTokenCookie)
+	if err != nil || cookie.Value == "" {
+		return "", errNoToken
+	}
+
+	// cookies issued before the header was supported carry the scheme too
+	return strings.TrimPrefix(cookie.Value, jwtPrefix), nil
+}
+
+func challenge(w http.ResponseWriter, status int, code string, description string) {
+	value := fmt.Sprintf("Bearer realm=%q", realm)
+	if code != "" {
+		value += fmt.Sprintf(", error=%q, error_description=%q", code, description)
+	}
+
+	w.Header().Set("WWW-Authenticate", value)
+	if code == "" {
+		resp.Fail(w, status, "authentication required")
+		return
+	}
+
+	resp.WriteProblem(w, resp.NewProblem(status, code, description))
+}
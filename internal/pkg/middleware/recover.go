@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"MovieService/internal/pkg/utils/logger"
+	resp "MovieService/internal/pkg/utils/responser"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover turns a panic of the handler into a JSON 500, if nothing has been
+// written yet, and logs it with the stack.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := wrap(w)
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			logger.FromContext(r.Context()).Error("panic while serving request",
+				"panic", v, "stack", string(debug.Stack()))
+			if !rw.wroteHeader {
+				resp.Fail(rw, http.StatusInternalServerError, "internal server error")
+			}
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"MovieService/internal/pkg/utils/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+)
+
+const (
+	RequestIdHeader = "X-Request-ID"
+
+	// incoming ids longer than this are replaced, they end up in every log line
+	maxRequestIdLen = 128
+)
+
+type requestIdKey struct{}
+
+func RequestIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// RequestID keeps the X-Request-ID of the client or generates one, returns it
+// in the response and puts a logger carrying it into the request context.
+func RequestID(log *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIdHeader)
+			if !validRequestId(id) {
+				id = newRequestId()
+			}
+
+			w.Header().Set(RequestIdHeader, id)
+			ctx := context.WithValue(r.Context(), requestIdKey{}, id)
+			ctx = logger.WithContext(ctx, log.With("requestId", id))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,11 @@
+package movies
+
+import "MovieService/internal/pkg/apperr"
+
+var (
+	ErrMovieNotFound   = apperr.NotFound("movie_not_found", "movie not found")
+	ErrUnknownSorting  = apperr.BadRequest("unknown_sorting", "unknown sorting")
+	ErrMovieChanged    = apperr.PreconditionFailed("movie_changed", "the movie has been changed or deleted meanwhile")
+	ErrActorInMovie    = apperr.Conflict("actor_in_movie", "the actor is already in the cast of the movie")
+	ErrActorNotInMovie = apperr.NotFound("actor_not_in_movie", "the actor is not in the cast of the movie")
+)
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/apperr"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// parseFilter reads the filter parameters of the movie list. A year stands for
+// the release dates from its first to its last day.
+func parseFilter(query url.Values) (*models.MovieFilter, error) {
+	fields := make(map[string]string)
+	filter := &models.MovieFilter{
+		NamePrefix:          query.Get("name_prefix"),
+		DescriptionContains: query.Get("description"),
+	}
+
+	number := func(name string, min, max int) *int {
+		v := query.Get(name)
+		if v == "" {
+			return nil
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil || n < min || n > max {
+			fields[name] = "must be a number from " + strconv.Itoa(min) + " to " + strconv.Itoa(max)
+			return nil
+		}
+
+		return &n
+	}
+
+	date := func(name string) *time.Time {
+		v := query.Get(name)
+		if v == "" {
+			return nil
+		}
+
+		t, err := time.Parse(dateLayout, v)
+		if err != nil {
+			fields[name] = "must be a date as YYYY-MM-DD"
+			return nil
+		}
+
+		return &t
+	}
+
+	filter.MinRating = number("rating_min", 0, 10)
+	filter.MaxRating = number("rating_max", 0, 10)
+	if filter.MinRating != nil && filter.MaxRating != nil && *filter.MinRating > *filter.MaxRating {
+		fields["rating_min"] = "must not exceed rating_max"
+	}
+
+	filter.ReleasedFrom = date("released_from")
+	filter.ReleasedTo = date("released_to")
+	if filter.ReleasedFrom != nil && filter.ReleasedTo != nil && filter.ReleasedFrom.After(*filter.ReleasedTo) {
+		fields["released_from"] = "must not be after released_to"
+	}
+
+	if year := number("year", 1, 9999); year != nil {
+		if query.Get("released_from") != "" || query.Get("released_to") != "" {
+			fields["year"] = "cannot be combined with released_from or released_to"
+		}
+
+		from := time.Date(*year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(*year, time.December, 31, 0, 0, 0, 0, time.UTC)
+		filter.ReleasedFrom, filter.ReleasedTo = &from, &to
+	}
+
+	filter.WithActor = number("actor", 1, math.MaxInt32)
+	filter.WithoutActor = number("without_actor", 1, math.MaxInt32)
+
+	if len(fields) != 0 {
+		err := apperr.BadRequest("invalid_query", "invalid query parameters")
+		err.Fields = fields
+		return nil, err
+	}
+
+	return filter, nil
+}
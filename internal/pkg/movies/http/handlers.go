@@ -3,131 +3,189 @@ package http
 import (
 	"MovieService/internal/models"
 	"MovieService/internal/pkg/movies"
+	"MovieService/internal/pkg/utils/etag"
+	"MovieService/internal/pkg/utils/mergepatch"
+	"MovieService/internal/pkg/utils/pagination"
 	resp "MovieService/internal/pkg/utils/responser"
+	"MovieService/internal/pkg/utils/router"
+	"MovieService/internal/pkg/utils/sorting"
 	"encoding/json"
-	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
-	"path/filepath"
-	"regexp"
-	"strconv"
-	"strings"
-)
-
-var (
-	allMoviesRe            = regexp.MustCompile(`^\/api\/movies((\?.*)|(\/*))$`)
-	moviesBySearchRe       = regexp.MustCompile(`^\/api\/movies\/search\?.*$`)
-	addMovieRe             = regexp.MustCompile(`^\/api\/movies[\/]*$`)
-	updateMovieRe          = regexp.MustCompile(`^\/api\/movies\/(\d+)$`)
-	deleteMovieRe          = regexp.MustCompile(`^\/api\/movies\/(\d+)$`)
-	deleteActorFromMovieRe = regexp.MustCompile(`^\/api\/movies\/(\d+)\/actors[\/]*$`)
-	addActorToMovieRe      = regexp.MustCompile(`^\/api\/movies\/(\d+)\/actors[\/]*$`)
 )
 
 type MoviesHandler struct {
-	log *slog.Logger
-	uc  movies.MoviesUsecase
+	uc movies.MoviesUsecase
 }
 
-func NewMoviesHandler(log *slog.Logger, uc movies.MoviesUsecase) MoviesHandler {
+func NewMoviesHandler(uc movies.MoviesUsecase) MoviesHandler {
 	return MoviesHandler{
-		log: log,
-		uc:  uc,
-	}
-}
-
-// TODO разделить поиск по названию и актеру
-func (mh *MoviesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("content-type", "application/json")
-	fmt.Println(r.URL.RequestURI())
-	switch {
-	case r.Method == http.MethodGet && allMoviesRe.MatchString(r.URL.RequestURI()):
-		mh.GetMovies(w, r)
-		return
-	case r.Method == http.MethodGet && moviesBySearchRe.MatchString(r.URL.RequestURI()):
-		mh.GetMoviesBySearch(w, r)
-		return
-	case r.Method == http.MethodPost && addMovieRe.MatchString(r.URL.RequestURI()):
-		mh.AddMovie(w, r)
-		return
-	case r.Method == http.MethodPut && updateMovieRe.MatchString(r.URL.RequestURI()):
-		mh.UpdateMovie(w, r)
-		return
-	case r.Method == http.MethodDelete && deleteMovieRe.MatchString(r.URL.RequestURI()):
-		mh.DeleteMovie(w, r)
-		return
-	case r.Method == http.MethodPost && addActorToMovieRe.MatchString(r.URL.Path):
-		mh.AddActorToMovie(w, r)
-		return
-	case r.Method == http.MethodDelete && deleteActorFromMovieRe.MatchString(r.URL.Path):
-		mh.DeleteActorFromMovie(w, r)
-	default:
-		resp.JSONStatus(w, http.StatusNotFound)
+		uc: uc,
 	}
 }
 
 // GetMovies godoc
 // @Summary      Get list of movies
-// @Description  Retrieves a list of movies based on the provided parameters
+// @Description  Retrieves a page of the movies matching all the given filters in the given order. The Link header points to the first, previous and next pages.
 // @Tags         Movies
 // @Produce      json
-// @Param        sorting   query    string  false  "Query string to sort movies"
-// @Success      200  {array}  models.Movie
-// @Failure      500
+// @Param        sort      query    string  false  "Sort order, fields separated by commas and descending with a minus, e.g. -rating,name. Fields: name, release_date, rating, id. -rating by default"
+// @Param        sorting   query    string  false  "Deprecated sort mode: name_asc, name_desc, date_asc, date_desc, rating_asc or rating_desc"
+// @Param        rating_min      query    int     false  "Lowest rating, from 0 to 10"
+// @Param        rating_max      query    int     false  "Highest rating, from 0 to 10"
+// @Param        released_from   query    string  false  "Earliest release date, YYYY-MM-DD"
+// @Param        released_to     query    string  false  "Latest release date, YYYY-MM-DD"
+// @Param        year            query    int     false  "Release year, not combinable with released_from and released_to"
+// @Param        actor           query    int     false  "Id of an actor who plays in the movie"
+// @Param        without_actor   query    int     false  "Id of an actor who does not play in the movie"
+// @Param        name_prefix     query    string  false  "Start of the movie name, case-insensitive"
+// @Param        description     query    string  false  "Fragment of the description, case-insensitive"
+// @Param        limit     query    int     false  "Page size, 20 by default and 100 at most"
+// @Param        offset    query    int     false  "Number of movies to skip, not combinable with cursor"
+// @Param        cursor    query    string  false  "Cursor of the page to read, the next field of the previous page"
+// @Param        count     query    bool    false  "Count the matching movies into total"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.MoviePage
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
 // @Router       /api/movies [get]
 func (mh *MoviesHandler) GetMovies(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("get movies")
-	sort := r.URL.Query().Get("sorting")
+	page, err := pagination.Parse(r.URL.Query())
+	if err != nil {
+		resp.Error(w, r, err)
+		return
+	}
 
-	if sort == "" {
-		sort = movies.RATING_DESC
+	filter, err := parseFilter(r.URL.Query())
+	if err != nil {
+		resp.Error(w, r, err)
+		return
+	}
+
+	sort, err := sortOrder(r)
+	if err != nil {
+		resp.Error(w, r, err)
+		return
 	}
 
-	movies, err := mh.uc.GetMovies(r.Context(), sort)
+	movies, err := mh.uc.GetMovies(r.Context(), filter, sort, page)
 	if err != nil {
-		fmt.Println(err)
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		resp.Error(w, r, err)
 		return
 	}
 
+	pagination.SetLinks(w, r, page, movies.Next)
 	resp.JSON(w, http.StatusOK, movies)
 }
 
 // GetMoviesBySearch godoc
-// @Summary      Get list of movies
-// @Description  Retrieves a list of movies based on the provided parameters
+// @Summary      Search movies
+// @Description  With q, retrieves a page of the movies whose name or description matches the full-text query, the most relevant first and with the matches highlighted. Words must all match, "quoted words" match as a phrase, word* as a prefix, -word or -"words" must not match and OR between two terms lets either match. Words are stemmed as Russian or English.
+// @Description  With movie_name or actor_name, retrieves a page of models.MoviePage with the movies whose name or one of whose actors contains the fragment.
 // @Tags         Movies
 // @Produce      json
+// @Param        q            query    string  false  "Full-text query, e.g. star* -clone OR empire"
 // @Param        movie_name   query    string  false  "Name of movie to filter movies"
 // @Param        actor_name   query    string  false  "Name of actor to filter movies"
-// @Success      200  {array}  models.Movie
-// @Failure      400
-// @Failure      500
+// @Param        sort      query    string  false  "Sort order, as for the movie list, not used with q"
+// @Param        sorting   query    string  false  "Deprecated sort mode, as for the movie list, not used with q"
+// @Param        limit     query    int     false  "Page size, 20 by default and 100 at most"
+// @Param        offset    query    int     false  "Number of movies to skip, not combinable with cursor"
+// @Param        cursor    query    string  false  "Cursor of the page to read, the next field of the previous page"
+// @Param        count     query    bool    false  "Count the matching movies into total"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.MovieSearchPage
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
 // @Router       /api/movies/search [get]
 func (mh *MoviesHandler) GetMoviesBySearch(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("get search movies")
+	q := r.URL.Query().Get("q")
 	movieName := r.URL.Query().Get("movie_name")
 	actorName := r.URL.Query().Get("actor_name")
 
+	if q != "" && (movieName != "" || actorName != "") {
+		resp.Fail(w, http.StatusBadRequest, "q cannot be combined with movie_name or actor_name")
+		return
+	}
 	if movieName != "" && actorName != "" {
-		resp.JSONStatus(w, http.StatusBadRequest)
-	} else if movieName == "" && actorName != "" {
-		movies, err := mh.uc.GetMoviesByActorName(r.Context(), actorName)
-		fmt.Println(err)
+		resp.Fail(w, http.StatusBadRequest, "movie_name and actor_name cannot be combined")
+		return
+	}
+
+	page, err := pagination.Parse(r.URL.Query())
+	if err != nil {
+		resp.Error(w, r, err)
+		return
+	}
+
+	if q != "" {
+		hits, err := mh.uc.SearchMovies(r.Context(), q, page)
 		if err != nil {
-			resp.JSONStatus(w, http.StatusInternalServerError)
+			resp.Error(w, r, err)
 			return
 		}
-		resp.JSON(w, http.StatusOK, movies)
+
+		pagination.SetLinks(w, r, page, hits.Next)
+		resp.JSON(w, http.StatusOK, hits)
+		return
+	}
+
+	sort, err := sortOrder(r)
+	if err != nil {
+		resp.Error(w, r, err)
+		return
+	}
+
+	var movies *models.MoviePage
+	if actorName != "" {
+		movies, err = mh.uc.GetMoviesByActorName(r.Context(), actorName, sort, page)
 	} else {
-		movies, err := mh.uc.GetMoviesByMovieName(r.Context(), movieName)
-		if err != nil {
-			resp.JSONStatus(w, http.StatusInternalServerError)
-			return
-		}
-		resp.JSON(w, http.StatusOK, movies)
+		movies, err = mh.uc.GetMoviesByMovieName(r.Context(), movieName, sort, page)
+	}
+	if err != nil {
+		resp.Error(w, r, err)
+		return
+	}
+
+	pagination.SetLinks(w, r, page, movies.Next)
+	resp.JSON(w, http.StatusOK, movies)
+}
+
+// GetMovie godoc
+// @Summary      Get movie by ID
+// @Description  Retrieves the movie with the given ID, answers 304 when If-None-Match lists its ETag
+// @Tags         Movies
+// @Produce      json
+// @Param        id  path  int  true  "Movie ID"
+// @Param        If-None-Match  header  string  false  "ETag of the cached movie"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.Movie
+// @Header       200  {string}  ETag  "ETag of the movie"
+// @Success      304
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/movies/{id} [get]
+func (mh *MoviesHandler) GetMovie(w http.ResponseWriter, r *http.Request) {
+	movie, err := mh.uc.GetMovie(r.Context(), router.Int(r, "id"))
+	if err != nil {
+		resp.Error(w, r, err)
+		return
 	}
+
+	if etag.NotModified(w, r, movie) {
+		return
+	}
+
+	resp.JSON(w, http.StatusOK, movie)
 }
 
 // AddMovie godoc
@@ -136,14 +194,19 @@ func (mh *MoviesHandler) GetMoviesBySearch(w http.ResponseWriter, r *http.Reques
 // @Tags         Movies
 // @Accept       json
 // @Param        movie  body  models.Movie  true  "Movie information"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Success      200
-// @Failure      400
-// @Failure      500
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      422  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
 // @Router       /api/movies [post]
 func (mh *MoviesHandler) AddMovie(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("add movie")
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		badRequest(w)
 		return
 	}
 	defer r.Body.Close()
@@ -151,13 +214,13 @@ func (mh *MoviesHandler) AddMovie(w http.ResponseWriter, r *http.Request) {
 	m := &models.Movie{}
 	err = json.Unmarshal(body, m)
 	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
+		badRequest(w)
+		return
 	}
 
 	err = mh.uc.AddMovie(r.Context(), m)
 	if err != nil {
-		fmt.Println(err)
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		resp.Error(w, r, err)
 		return
 	}
 
@@ -165,43 +228,93 @@ func (mh *MoviesHandler) AddMovie(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateMovie godoc
-// @Summary      Update movie by ID
-// @Description  Updates a movie with the given ID
+// @Summary      Replace movie by ID
+// @Description  Replaces every field of the movie with the given ID, the cast is kept
 // @Tags         Movies
 // @Accept       json
+// @Produce      json
 // @Param        id  path  int  true  "Movie ID"
-// @Param        movie  body  models.Movie  true  "Movie information to update"
-// @Success      200
-// @Failure      400
-// @Failure      500
-// @Router       /api/movie/{id} [put]
+// @Param        If-Match  header  string  false  "ETag of the movie as last read"
+// @Param        movie  body  models.Movie  true  "Movie information"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.Movie
+// @Header       200  {string}  ETag  "ETag of the movie"
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      412  {object}  resp.Problem
+// @Failure      422  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/movies/{id} [put]
 func (mh *MoviesHandler) UpdateMovie(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("update movies")
-	idStr := filepath.Base(r.URL.Path)
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
-		return
-	}
+	id := router.Int(r, "id")
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		badRequest(w)
 		return
 	}
 	defer r.Body.Close()
 
 	m := &models.Movie{}
 	err = json.Unmarshal(body, m)
+	if err != nil {
+		badRequest(w)
+		return
+	}
 	m.Id = id
 
-	err = mh.uc.UpdateMovie(r.Context(), m)
+	updated, err := mh.uc.UpdateMovie(r.Context(), m, etag.IfMatch(r))
+	if err != nil {
+		resp.Error(w, r, err)
+		return
+	}
 
+	etag.Set(w, updated)
+	resp.JSON(w, http.StatusOK, updated)
+}
+
+// PatchMovie godoc
+// @Summary      Patch movie by ID
+// @Description  Changes the fields of the movie present in a JSON merge patch (RFC 7396), null clears a field
+// @Tags         Movies
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        id  path  int  true  "Movie ID"
+// @Param        If-Match  header  string  false  "ETag of the movie as last read"
+// @Param        movie  body  models.Movie  true  "Fields to change"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.Movie
+// @Header       200  {string}  ETag  "ETag of the movie"
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      412  {object}  resp.Problem
+// @Failure      415  {object}  resp.Problem
+// @Failure      422  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
+// @Router       /api/movies/{id} [patch]
+func (mh *MoviesHandler) PatchMovie(w http.ResponseWriter, r *http.Request) {
+	id := router.Int(r, "id")
+
+	patch := &models.MoviePatch{}
+	if err := mergepatch.Decode(r, patch); err != nil {
+		resp.Error(w, r, err)
+		return
+	}
+
+	updated, err := mh.uc.PatchMovie(r.Context(), id, patch, etag.IfMatch(r))
 	if err != nil {
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		resp.Error(w, r, err)
 		return
 	}
 
-	resp.JSONStatus(w, http.StatusOK)
+	etag.Set(w, updated)
+	resp.JSON(w, http.StatusOK, updated)
 }
 
 // DeleteMovie godoc
@@ -210,67 +323,66 @@ func (mh *MoviesHandler) UpdateMovie(w http.ResponseWriter, r *http.Request) {
 // @Tags         Movies
 // @Accept       json
 // @Param        id  path  int  true  "Movie ID"
+// @Param        If-Match  header  string  false  "ETag of the movie as last read"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Success      200
-// @Failure      400
-// @Failure      500
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      412  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
 // @Router       /api/movies/{id} [delete]
 func (mh *MoviesHandler) DeleteMovie(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("delete movies")
-	idStr := filepath.Base(r.URL.Path)
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
-		return
-	}
+	id := router.Int(r, "id")
 
-	err = mh.uc.DeleteMovie(r.Context(), id)
+	err := mh.uc.DeleteMovie(r.Context(), id, etag.IfMatch(r))
 
 	if err != nil {
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		resp.Error(w, r, err)
 		return
 	}
 
 	resp.JSONStatus(w, http.StatusOK)
 }
 
-//Наложить ограничение уникальности на ключи в м-м
-
 // AddActorToMovie godoc
 // @Summary      Add an actor to movie
 // @Description  Add an actor to movie by their ids
 // @Tags         Movies
 // @Accept       json
 // @Param        id  path  int  true  "Movie ID"
-// @Param        id  body  int  true  "Actor id"
+// @Param        actor  body  models.ActorInMovieSlice  true  "Actor to add, only the id is read"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Success      200
-// @Failure      400
-// @Failure      500
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      409  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
 // @Router       /api/movies/{id}/actors [post]
 func (mh *MoviesHandler) AddActorToMovie(w http.ResponseWriter, r *http.Request) {
-	sliceOfURL := strings.Split(r.URL.Path, "/")
-	id, err := strconv.Atoi(sliceOfURL[1])
-	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
-		return
-	}
+	id := router.Int(r, "id")
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
+		badRequest(w)
 		return
 	}
 
-	m := make(map[string]interface{})
-	err = json.Unmarshal(body, &m)
-	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
+	actor := &models.ActorInMovieSlice{}
+	err = json.Unmarshal(body, actor)
+	if err != nil || actor.Id == 0 {
+		resp.FailFields(w, http.StatusBadRequest, "invalid request body", map[string]string{"id": "is required"})
 		return
 	}
 
-	actorId := m["id"].(int)
-	err = mh.uc.AddActorToMovie(r.Context(), id, actorId)
+	err = mh.uc.AddActorToMovie(r.Context(), id, actor.Id)
 	if err != nil {
-		resp.JSONStatus(w, http.StatusInternalServerError)
+		resp.Error(w, r, err)
 		return
 	}
 
@@ -284,29 +396,54 @@ func (mh *MoviesHandler) AddActorToMovie(w http.ResponseWriter, r *http.Request)
 // @Accept       json
 // @Param        movieId  path  int  true  "Movie ID"
 // @Param        actorId  path  int  true  "Actor id"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Success      200
-// @Failure      400
-// @Failure      500
+// @Failure      400  {object}  resp.Problem
+// @Failure      401  {object}  resp.Problem
+// @Failure      403  {object}  resp.Problem
+// @Failure      404  {object}  resp.Problem
+// @Failure      500  {object}  resp.Problem
 // @Router       /api/movies/{movieId}/actors/{actorId} [delete]
 func (mh *MoviesHandler) DeleteActorFromMovie(w http.ResponseWriter, r *http.Request) {
-	sliceOfURL := strings.Split(r.URL.Path, "/")
-	movieId, err := strconv.Atoi(sliceOfURL[2])
-	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
-		return
-	}
+	movieId := router.Int(r, "movieId")
+	actorId := router.Int(r, "actorId")
 
-	actorId, err := strconv.Atoi(sliceOfURL[4])
+	err := mh.uc.DeleteActorFromMovie(r.Context(), movieId, actorId)
 	if err != nil {
-		resp.JSONStatus(w, http.StatusBadRequest)
+		resp.Error(w, r, err)
 		return
 	}
 
-	err = mh.uc.DeleteActorFromMovie(r.Context(), movieId, actorId)
-	if err != nil {
-		resp.JSONStatus(w, http.StatusInternalServerError)
-		return
+	resp.JSONStatus(w, http.StatusOK)
+}
+
+// legacySorting are the sort orders of the sort modes of the sorting
+// parameter, still accepted when sort is not given.
+var legacySorting = map[string]string{
+	movies.NAME_ASC:    "name",
+	movies.NAME_DESC:   "-name",
+	movies.DATE_ASC:    "release_date",
+	movies.DATE_DESC:   "-release_date",
+	movies.RATING_ASC:  "rating",
+	movies.RATING_DESC: "-rating",
+}
+
+func sortOrder(r *http.Request) ([]models.SortKey, error) {
+	spec := r.URL.Query().Get("sort")
+	if spec == "" {
+		spec = movies.DefaultSort
+		if mode := r.URL.Query().Get("sorting"); mode != "" {
+			var ok bool
+			if spec, ok = legacySorting[mode]; !ok {
+				return nil, movies.ErrUnknownSorting
+			}
+		}
 	}
 
-	resp.JSONStatus(w, http.StatusOK)
+	return sorting.Parse(spec, movies.SortFields...)
+}
+
+func badRequest(w http.ResponseWriter) {
+	resp.Fail(w, http.StatusBadRequest, "invalid request body")
 }
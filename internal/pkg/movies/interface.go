@@ -2,9 +2,16 @@ package movies
 
 import (
 	"MovieService/internal/models"
+	"MovieService/internal/pkg/utils/etag"
 	"context"
 )
 
+// SortFields are the fields the movie listings can be sorted by.
+var SortFields = []string{"name", "release_date", "rating", "id"}
+
+const DefaultSort = "-rating"
+
+// Sort modes of the sorting parameter, which the sort parameter supersedes.
 const (
 	NAME_ASC    = "name_asc"
 	NAME_DESC   = "name_desc"
@@ -15,24 +22,28 @@ const (
 )
 
 type MoviesRepo interface {
-	ReadMovies(context.Context, string) ([]models.Movie, error)
+	ReadMovies(context.Context, *models.MovieFilter, []models.SortKey, *models.PageRequest) (*models.MoviePage, error)
 	ReadMovie(context.Context, int) (*models.Movie, error)
 	CreateMovie(context.Context, *models.Movie) (int, error)
 	UpdateMovie(context.Context, *models.Movie) error
-	DeleteMovie(context.Context, int) error
-	ReadMoviesByMovieName(context.Context, string) ([]models.Movie, error)
-	ReadMoviesByActorName(context.Context, string) ([]models.Movie, error)
+	DeleteMovie(context.Context, int, int) error
+	ReadMoviesByMovieName(context.Context, string, []models.SortKey, *models.PageRequest) (*models.MoviePage, error)
+	ReadMoviesByActorName(context.Context, string, []models.SortKey, *models.PageRequest) (*models.MoviePage, error)
+	SearchMovies(context.Context, string, *models.PageRequest) (*models.MovieSearchPage, error)
 	AddActorToMovie(context.Context, int, int) error
 	DeleteActorFromMovie(context.Context, int, int) error
 }
 
 type MoviesUsecase interface {
-	GetMovies(context.Context, string) ([]models.Movie, error)
+	GetMovies(context.Context, *models.MovieFilter, []models.SortKey, *models.PageRequest) (*models.MoviePage, error)
+	GetMovie(context.Context, int) (*models.Movie, error)
 	AddMovie(context.Context, *models.Movie) error
-	UpdateMovie(context.Context, *models.Movie) error
-	DeleteMovie(context.Context, int) error
-	GetMoviesByMovieName(context.Context, string) ([]models.Movie, error)
-	GetMoviesByActorName(context.Context, string) ([]models.Movie, error)
+	UpdateMovie(context.Context, *models.Movie, etag.Precondition) (*models.Movie, error)
+	PatchMovie(context.Context, int, *models.MoviePatch, etag.Precondition) (*models.Movie, error)
+	DeleteMovie(context.Context, int, etag.Precondition) error
+	GetMoviesByMovieName(context.Context, string, []models.SortKey, *models.PageRequest) (*models.MoviePage, error)
+	GetMoviesByActorName(context.Context, string, []models.SortKey, *models.PageRequest) (*models.MoviePage, error)
+	SearchMovies(context.Context, string, *models.PageRequest) (*models.MovieSearchPage, error)
 	AddActorToMovie(context.Context, int, int) error
 	DeleteActorFromMovie(context.Context, int, int) error
 }
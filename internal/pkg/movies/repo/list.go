@@ -0,0 +1,223 @@
+package repo
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/movies"
+	"MovieService/internal/pkg/utils/pagination"
+	"MovieService/internal/pkg/utils/sorting"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	listMovies  = "SELECT m.id, m.name, m.description, m.release_date, m.rating, m.version FROM movie AS m"
+	countMovies = "SELECT count(*) FROM movie AS m"
+
+	byMovieName = "LOWER(m.name) LIKE LOWER($%d)"
+	byActorName = "EXISTS (SELECT 1 FROM movie_actor AS ma JOIN actor AS a ON ma.actor_id = a.id " +
+		"WHERE ma.movie_id = m.id AND (LOWER(a.name) LIKE LOWER($%[1]d) OR LOWER(a.surname) LIKE LOWER($%[1]d)))"
+	withActor = "EXISTS (SELECT 1 FROM movie_actor AS ma WHERE ma.movie_id = m.id AND ma.actor_id = $%d)"
+)
+
+// sortColumns are the columns of the sort fields. Every order ends with the
+// id so that movies with equal values keep their place between pages.
+var sortColumns = map[string]string{
+	"name":         "m.name",
+	"release_date": "m.release_date",
+	"rating":       "m.rating",
+	"id":           "m.id",
+}
+
+func (mr *MoviesRepo) ReadMovies(ctx context.Context, filter *models.MovieFilter, sort []models.SortKey, page *models.PageRequest) (*models.MoviePage, error) {
+	conds, args := filterConds(filter)
+	return mr.list(ctx, conds, args, sort, page)
+}
+
+func (mr *MoviesRepo) ReadMoviesByMovieName(ctx context.Context, movieName string, sort []models.SortKey, page *models.PageRequest) (*models.MoviePage, error) {
+	return mr.list(ctx, []string{fmt.Sprintf(byMovieName, 1)}, []any{"%" + movieName + "%"}, sort, page)
+}
+
+func (mr *MoviesRepo) ReadMoviesByActorName(ctx context.Context, actorName string, sort []models.SortKey, page *models.PageRequest) (*models.MoviePage, error) {
+	return mr.list(ctx, []string{fmt.Sprintf(byActorName, 1)}, []any{"%" + actorName + "%"}, sort, page)
+}
+
+// filterConds translates the filter into conditions on the movie m, every
+// value bound to a placeholder of args.
+func filterConds(filter *models.MovieFilter) ([]string, []any) {
+	conds := make([]string, 0)
+	args := make([]any, 0)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter == nil {
+		return conds, args
+	}
+	if filter.MinRating != nil {
+		add("m.rating >= $%d", *filter.MinRating)
+	}
+	if filter.MaxRating != nil {
+		add("m.rating <= $%d", *filter.MaxRating)
+	}
+	if filter.ReleasedFrom != nil {
+		add("m.release_date >= $%d", *filter.ReleasedFrom)
+	}
+	if filter.ReleasedTo != nil {
+		add("m.release_date <= $%d", *filter.ReleasedTo)
+	}
+	if filter.WithActor != nil {
+		add(withActor, *filter.WithActor)
+	}
+	if filter.WithoutActor != nil {
+		add("NOT "+withActor, *filter.WithoutActor)
+	}
+	if filter.NamePrefix != "" {
+		add("starts_with(LOWER(m.name), LOWER($%d))", filter.NamePrefix)
+	}
+	if filter.DescriptionContains != "" {
+		add("strpos(LOWER(m.description), LOWER($%d)) > 0", filter.DescriptionContains)
+	}
+
+	return conds, args
+}
+
+// list reads a page of the movies matching all the conditions, bound to args,
+// in the sort order.
+func (mr *MoviesRepo) list(ctx context.Context, conds []string, args []any, sort []models.SortKey, page *models.PageRequest) (*models.MoviePage, error) {
+	keys, ok := pagination.Keys(sort, sortColumns, "m.id")
+	if !ok {
+		return nil, movies.ErrUnknownSorting
+	}
+
+	result := &models.MoviePage{
+		Movies: make([]models.Movie, 0),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+
+	if page.Count {
+		var total int
+		err := mr.db.QueryRow(ctx, countMovies+where(conds), args...).Scan(&total)
+		if err != nil {
+			err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	listing := "movies:" + sorting.String(sort)
+	if page.Cursor != "" {
+		after := make([]any, len(keys))
+		for i, key := range keys {
+			after[i] = keyDest(key.Column)
+		}
+
+		if err := pagination.DecodeCursor(page.Cursor, listing, after...); err != nil {
+			return nil, err
+		}
+
+		conds = append(conds, pagination.After(keys, len(args)+1))
+		args = append(args, after...)
+	}
+
+	// one more row than asked tells whether there is a next page
+	query := fmt.Sprintf("%s%s ORDER BY %s LIMIT %d OFFSET %d",
+		listMovies, where(conds), pagination.Order(keys), page.Limit+1, page.Offset)
+	rows, err := mr.db.Query(ctx, query, args...)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Query: %w", err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	movie := models.Movie{}
+	for rows.Next() {
+		err = rows.Scan(
+			&movie.Id,
+			&movie.Name,
+			&movie.Description,
+			&movie.ReleaseDate,
+			&movie.Rating,
+			&movie.Version,
+		)
+		if err != nil {
+			err = fmt.Errorf("error happened in rows.Scan: %w", err)
+
+			return nil, err
+		}
+
+		result.Movies = append(result.Movies, movie)
+	}
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error happened in rows.Next: %w", err)
+
+		return nil, err
+	}
+	rows.Close()
+
+	if len(result.Movies) > page.Limit {
+		result.Movies = result.Movies[:page.Limit]
+		last := &result.Movies[page.Limit-1]
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = keyValue(last, key.Column)
+		}
+		result.Next = pagination.EncodeCursor(listing, values...)
+	}
+
+	ids := make([]int, len(result.Movies))
+	for i := range result.Movies {
+		ids[i] = result.Movies[i].Id
+	}
+
+	casts, err := mr.readCasts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.Movies {
+		result.Movies[i].Actors = casts[result.Movies[i].Id]
+	}
+
+	return result, nil
+}
+
+func where(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// keyValue and keyDest are the value of a sort column for the cursor of a
+// movie and the destination to decode it from a cursor.
+func keyValue(m *models.Movie, column string) any {
+	switch column {
+	case "m.name":
+		return m.Name
+	case "m.release_date":
+		return m.ReleaseDate
+	case "m.rating":
+		return m.Rating
+	}
+
+	return m.Id
+}
+
+func keyDest(column string) any {
+	switch column {
+	case "m.name":
+		return new(string)
+	case "m.release_date":
+		return new(pgtype.Date)
+	}
+
+	return new(int)
+}
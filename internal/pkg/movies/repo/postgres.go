@@ -2,27 +2,29 @@ package repo
 
 import (
 	"MovieService/internal/models"
+	"MovieService/internal/pkg/actors"
 	"MovieService/internal/pkg/movies"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
-	readMovies            = "SELECT id, name, description, release_date, rating FROM movie "
-	readeMovie            = "SELECT name, description, release_date, rating FROM movie WHERE id=$1;"
-	readMoviesByMovieName = "SELECT id, name, description, release_date, rating FROM movie WHERE LOWER(name) LIKE LOWER($1);"
-	createMovie           = "INSERT INTO movie (name, description, release_date, rating) VALUES ($1, $2, $3, $4) RETURNING id;"
-	updateMovie           = "UPDATE movie SET name=$1, description=$2, release_date=$3, rating=$4 WHERE id=$5;"
-	deleteMovie           = "DELETE FROM movie WHERE id=$1;"
-	readActorsOfMovie     = "SELECT a.id, a.name, a.surname, a.gender, a.birth_date FROM actor AS a JOIN movie_actor AS ma ON ma.actor_id = a.id WHERE ma.movie_id=$1"
-	createActorMovie      = "INSERT INTO movie_actor (movie_id, actor_id) VALUES ($1, $2);"
-	deleteActorMovie      = "DELETE FROM movie_actor WHERE movie_id=$1 AND actor_id=$2;"
-	readMoviesActorName   = "SELECT m.id, m.name, m.description, m.release_date, m.rating FROM movie AS m " +
-		"JOIN movie_actor AS ma ON m.id=ma.movie_id " +
-		"JOIN actor AS a ON ma.actor_id=a.id WHERE LOWER(a.name) LIKE LOWER($1) OR a.surname LIKE $1;"
+	readeMovie         = "SELECT name, description, release_date, rating, version FROM movie WHERE id=$1;"
+	createMovie        = "INSERT INTO movie (name, description, release_date, rating) VALUES ($1, $2, $3, $4) RETURNING id;"
+	updateMovie        = "UPDATE movie SET name=$1, description=$2, release_date=$3, rating=$4, version=version+1 WHERE id=$5 AND version=$6;"
+	deleteMovie        = "DELETE FROM movie WHERE id=$1 AND version=$2;"
+	readActorsOfMovies = "SELECT ma.movie_id, a.id, a.name, a.surname, a.gender, a.birth_date FROM actor AS a JOIN movie_actor AS ma ON ma.actor_id = a.id WHERE ma.movie_id = ANY($1) ORDER BY ma.movie_id, a.id"
+	createActorMovie   = "INSERT INTO movie_actor (movie_id, actor_id) VALUES ($1, $2);"
+	deleteActorMovie   = "DELETE FROM movie_actor WHERE movie_id=$1 AND actor_id=$2;"
+
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	movieForeignKey     = "movie_actor_movie_id_fkey"
+	actorForeignKey     = "movie_actor_actor_id_fkey"
 )
 
 type MoviesRepo struct {
@@ -35,109 +37,73 @@ func NewMoviesRepo(db *pgxpool.Pool) *MoviesRepo {
 	}
 }
 
-func (mr *MoviesRepo) readActorsForMovie(ctx context.Context, movieId int) ([]models.ActorInMovieSlice, error) {
-	actorRows, err := mr.db.Query(ctx, readActorsOfMovie, movieId)
+// readCasts loads the actors of all the movies in one query, so that a page
+// of movies costs the same two queries whatever its size. Every movie gets a
+// cast, empty when nobody plays in it.
+func (mr *MoviesRepo) readCasts(ctx context.Context, movieIds []int) (map[int][]models.ActorInMovieSlice, error) {
+	casts := make(map[int][]models.ActorInMovieSlice, len(movieIds))
+	for _, id := range movieIds {
+		casts[id] = make([]models.ActorInMovieSlice, 0)
+	}
+	if len(movieIds) == 0 {
+		return casts, nil
+	}
+
+	rows, err := mr.db.Query(ctx, readActorsOfMovies, movieIds)
 	if err != nil {
-		err = fmt.Errorf("error happened in rows.Scan: %w", err)
+		err = fmt.Errorf("error happened in db.Query: %w", err)
 
-		return []models.ActorInMovieSlice{}, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	actorSlice := make([]models.ActorInMovieSlice, 0)
+	var movieId int
 	actor := models.ActorInMovieSlice{}
-	for actorRows.Next() {
-		err = actorRows.Scan(
+	for rows.Next() {
+		err = rows.Scan(
+			&movieId,
 			&actor.Id,
 			&actor.Name,
 			&actor.Surname,
 			&actor.Gender,
 			&actor.BirthDate,
 		)
-
 		if err != nil {
 			err = fmt.Errorf("error happened in rows.Scan: %w", err)
-			return []models.ActorInMovieSlice{}, err
-		}
-
-		actorSlice = append(actorSlice, actor)
-	}
 
-	return actorSlice, nil
-}
-
-func (mr *MoviesRepo) ReadMovies(ctx context.Context, sortType string) ([]models.Movie, error) {
-	var endExpr string
-	switch sortType {
-	case movies.NAME_ASC:
-		endExpr = "ORDER BY name;"
-		break
-	case movies.NAME_DESC:
-		endExpr = "ORDER BY name DESC;"
-		break
-	case movies.DATE_ASC:
-		endExpr = "ORDER BY release_date;"
-		break
-	case movies.DATE_DESC:
-		endExpr = "ORDER BY release_date DESC;"
-		break
-	case movies.RATING_ASC:
-		endExpr = "ORDER BY rating;"
-		break
-	case movies.RATING_DESC:
-		endExpr = "ORDER BY rating DESC;"
-		break
-	default:
-		return make([]models.Movie, 0), nil
-	}
-
-	movieSlice := make([]models.Movie, 0)
-	rows, err := mr.db.Query(ctx, readMovies+endExpr)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return []models.Movie{}, err
+			return nil, err
 		}
-		err = fmt.Errorf("error happened in db.QueryContext: %w", err)
 
-		return []models.Movie{}, err
+		casts[movieId] = append(casts[movieId], actor)
 	}
-	movie := models.Movie{}
-	for rows.Next() {
-		err = rows.Scan(
-			&movie.Id,
-			&movie.Name,
-			&movie.Description,
-			&movie.ReleaseDate,
-			&movie.Rating,
-		)
-		if err != nil {
-			err = fmt.Errorf("error happened in rows.Scan: %w", err)
-
-			return []models.Movie{}, err
-		}
-
-		movie.Actors, err = mr.readActorsForMovie(ctx, movie.Id)
-		if err != nil {
-			movie.Actors = []models.ActorInMovieSlice{}
-		}
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error happened in rows.Next: %w", err)
 
-		movieSlice = append(movieSlice, movie)
+		return nil, err
 	}
-	defer rows.Close()
 
-	return movieSlice, err
+	return casts, nil
 }
 
 func (mr *MoviesRepo) ReadMovie(ctx context.Context, id int) (*models.Movie, error) {
 	m := &models.Movie{Id: id}
-	if err := mr.db.QueryRow(ctx, readeMovie, id).
-		Scan(&m.Name, &m.Description, &m.ReleaseDate, &m.Rating); err != nil {
+	err := mr.db.QueryRow(ctx, readeMovie, id).
+		Scan(&m.Name, &m.Description, &m.ReleaseDate, &m.Rating, &m.Version)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &models.Movie{}, err
+			return &models.Movie{}, movies.ErrMovieNotFound
 		}
 		err = fmt.Errorf("error happened in row.Scan: %w", err)
 
 		return &models.Movie{}, err
 	}
+
+	casts, err := mr.readCasts(ctx, []int{id})
+	if err != nil {
+		return &models.Movie{}, err
+	}
+	m.Actors = casts[id]
+
 	return m, nil
 }
 
@@ -156,107 +122,51 @@ func (mr *MoviesRepo) CreateMovie(ctx context.Context, movie *models.Movie) (int
 }
 
 func (mr *MoviesRepo) UpdateMovie(ctx context.Context, movie *models.Movie) error {
-	_, err := mr.db.Exec(ctx, updateMovie, movie.Name, movie.Description, movie.ReleaseDate, movie.Rating, movie.Id)
+	tag, err := mr.db.Exec(ctx, updateMovie, movie.Name, movie.Description, movie.ReleaseDate, movie.Rating, movie.Id, movie.Version)
 	if err != nil {
 		err = fmt.Errorf("error happened in db.Exec: %w", err)
 
 		return err
 	}
 
-	return nil
-}
-
-func (mr *MoviesRepo) DeleteMovie(ctx context.Context, id int) error {
-	_, err := mr.db.Exec(ctx, deleteMovie, id)
-	if err != nil {
-		err = fmt.Errorf("error happened in db.Exec: %w", err)
-
-		return err
+	// the version is the one read before the change, someone else got in
+	// between
+	if tag.RowsAffected() == 0 {
+		return movies.ErrMovieChanged
 	}
 
 	return nil
 }
 
-func (mr *MoviesRepo) ReadMoviesByMovieName(ctx context.Context, movieName string) ([]models.Movie, error) {
-	movieSlice := make([]models.Movie, 0)
-	movieName = "%" + movieName + "%"
-	rows, err := mr.db.Query(ctx, readMoviesByMovieName, movieName)
+func (mr *MoviesRepo) DeleteMovie(ctx context.Context, id int, version int) error {
+	tag, err := mr.db.Exec(ctx, deleteMovie, id, version)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return []models.Movie{}, err
-		}
-		err = fmt.Errorf("error happened in db.QueryContext: %w", err)
-
-		return []models.Movie{}, err
-	}
-	movie := models.Movie{}
-	for rows.Next() {
-		err = rows.Scan(
-			&movie.Id,
-			&movie.Name,
-			&movie.Description,
-			&movie.ReleaseDate,
-			&movie.Rating,
-		)
-		if err != nil {
-			err = fmt.Errorf("error happened in rows.Scan: %w", err)
-
-			return []models.Movie{}, err
-		}
-
-		movie.Actors, err = mr.readActorsForMovie(ctx, movie.Id)
-		if err != nil {
-			movie.Actors = []models.ActorInMovieSlice{}
-		}
-
-		movieSlice = append(movieSlice, movie)
-	}
-	defer rows.Close()
-
-	return movieSlice, err
-}
-
-func (mr *MoviesRepo) ReadMoviesByActorName(ctx context.Context, actorName string) ([]models.Movie, error) {
-	movieSlice := make([]models.Movie, 0)
-	actorName = "%" + actorName + "%"
-	rows, err := mr.db.Query(ctx, readMoviesActorName, actorName)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return []models.Movie{}, err
-		}
-		err = fmt.Errorf("error happened in db.QueryContext: %w", err)
+		err = fmt.Errorf("error happened in db.Exec: %w", err)
 
-		return []models.Movie{}, err
+		return err
 	}
-	movie := models.Movie{}
-	for rows.Next() {
-		err = rows.Scan(
-			&movie.Id,
-			&movie.Name,
-			&movie.Description,
-			&movie.ReleaseDate,
-			&movie.Rating,
-		)
-		if err != nil {
-			err = fmt.Errorf("error happened in rows.Scan: %w", err)
 
-			return []models.Movie{}, err
-		}
-
-		movie.Actors, err = mr.readActorsForMovie(ctx, movie.Id)
-		if err != nil {
-			movie.Actors = []models.ActorInMovieSlice{}
-		}
-		movieSlice = append(movieSlice, movie)
+	if tag.RowsAffected() == 0 {
+		return movies.ErrMovieChanged
 	}
-	defer rows.Close()
 
-	return movieSlice, err
+	return nil
 }
 
 func (mr *MoviesRepo) AddActorToMovie(ctx context.Context, movieId int, actorId int) error {
 	_, err := mr.db.Exec(ctx, createActorMovie, movieId, actorId)
 
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == uniqueViolation:
+			return movies.ErrActorInMovie
+		case pgErr.Code == foreignKeyViolation && pgErr.ConstraintName == movieForeignKey:
+			return movies.ErrMovieNotFound
+		case pgErr.Code == foreignKeyViolation && pgErr.ConstraintName == actorForeignKey:
+			return actors.ErrActorNotFound
+		}
+	}
 	if err != nil {
 		err = fmt.Errorf("error happened in db.Exec: %w", err)
 
@@ -267,7 +177,7 @@ func (mr *MoviesRepo) AddActorToMovie(ctx context.Context, movieId int, actorId
 }
 
 func (mr *MoviesRepo) DeleteActorFromMovie(ctx context.Context, movieId int, actorId int) error {
-	_, err := mr.db.Exec(ctx, deleteActorMovie, movieId, actorId)
+	tag, err := mr.db.Exec(ctx, deleteActorMovie, movieId, actorId)
 
 	if err != nil {
 		err = fmt.Errorf("error happened in db.Exec: %w", err)
@@ -275,5 +185,9 @@ func (mr *MoviesRepo) DeleteActorFromMovie(ctx context.Context, movieId int, act
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return movies.ErrActorNotInMovie
+	}
+
 	return nil
 }
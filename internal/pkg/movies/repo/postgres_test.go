@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"MovieService/internal/pkg/utils/pgtest"
+	"context"
+	"fmt"
+	"testing"
+)
+
+// BenchmarkReadCasts compares loading the casts of a page of movies one
+// movie at a time, a query per movie, with loading them in one batch.
+func BenchmarkReadCasts(b *testing.B) {
+	db := pgtest.Open(b)
+	pgtest.Seed(b, db, 100, 200, 8)
+	mr := NewMoviesRepo(db)
+	ctx := context.Background()
+
+	for _, size := range []int{1, 20, 100} {
+		ids := make([]int, size)
+		for i := range ids {
+			ids[i] = i + 1
+		}
+
+		b.Run(fmt.Sprintf("per-row/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, id := range ids {
+					if _, err := mr.readCasts(ctx, []int{id}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("batched/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := mr.readCasts(ctx, ids); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
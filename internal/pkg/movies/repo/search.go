@@ -0,0 +1,119 @@
+package repo
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/utils/pagination"
+	"MovieService/internal/pkg/utils/tsquery"
+	"context"
+	"fmt"
+)
+
+const (
+	// the headlines are made for the page only, they are costly to make
+	searchMovies = "SELECT h.id, h.name, h.description, h.release_date, h.rating, h.version, h.rank, " +
+		"ts_headline('russian', h.name, h.q, 'HighlightAll=true, " + tsquery.Selectors + "'), " +
+		"ts_headline('russian', coalesce(h.description, ''), h.q, 'MaxFragments=2, MinWords=5, MaxWords=25, " + tsquery.Selectors + "') " +
+		"FROM (SELECT m.id, m.name, m.description, m.release_date, m.rating, m.version, %s AS rank, q " +
+		"FROM movie AS m, to_tsquery('russian', $1) AS q WHERE m.search @@ q%s ORDER BY %s LIMIT %d OFFSET %d) AS h " +
+		"ORDER BY h.rank DESC, h.id"
+	countHits = "SELECT count(*) FROM movie AS m WHERE m.search @@ to_tsquery('russian', $1)"
+	rank      = "ts_rank_cd(m.search, q)"
+	hits      = "movies:search"
+)
+
+var hitKeys = []pagination.Key{{Column: rank, Desc: true}, {Column: "m.id"}}
+
+// SearchMovies reads a page of the movies matching the tsquery, the best
+// ranked first.
+func (mr *MoviesRepo) SearchMovies(ctx context.Context, query string, page *models.PageRequest) (*models.MovieSearchPage, error) {
+	result := &models.MovieSearchPage{
+		Movies: make([]models.MovieHit, 0),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+
+	if page.Count {
+		var total int
+		err := mr.db.QueryRow(ctx, countHits, query).Scan(&total)
+		if err != nil {
+			err = fmt.Errorf("error happened in row.Scan: %w", err)
+
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	cond, args := "", []any{query}
+	if page.Cursor != "" {
+		var afterRank float32
+		var afterId int
+		if err := pagination.DecodeCursor(page.Cursor, hits+":"+query, &afterRank, &afterId); err != nil {
+			return nil, err
+		}
+
+		cond = " AND " + pagination.After(hitKeys, 2)
+		args = append(args, afterRank, afterId)
+	}
+
+	// one more row than asked tells whether there is a next page
+	rows, err := mr.db.Query(ctx, fmt.Sprintf(searchMovies,
+		rank, cond, pagination.Order(hitKeys), page.Limit+1, page.Offset), args...)
+	if err != nil {
+		err = fmt.Errorf("error happened in db.Query: %w", err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	hit := models.MovieHit{}
+	for rows.Next() {
+		err = rows.Scan(
+			&hit.Id,
+			&hit.Name,
+			&hit.Description,
+			&hit.ReleaseDate,
+			&hit.Rating,
+			&hit.Version,
+			&hit.Rank,
+			&hit.NameHighlight,
+			&hit.Snippet,
+		)
+		if err != nil {
+			err = fmt.Errorf("error happened in rows.Scan: %w", err)
+
+			return nil, err
+		}
+
+		hit.NameHighlight = tsquery.Highlight(hit.NameHighlight)
+		hit.Snippet = tsquery.Highlight(hit.Snippet)
+		result.Movies = append(result.Movies, hit)
+	}
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error happened in rows.Next: %w", err)
+
+		return nil, err
+	}
+	rows.Close()
+
+	if len(result.Movies) > page.Limit {
+		result.Movies = result.Movies[:page.Limit]
+		last := result.Movies[page.Limit-1]
+		result.Next = pagination.EncodeCursor(hits+":"+query, last.Rank, last.Id)
+	}
+
+	ids := make([]int, len(result.Movies))
+	for i := range result.Movies {
+		ids[i] = result.Movies[i].Id
+	}
+
+	casts, err := mr.readCasts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.Movies {
+		result.Movies[i].Actors = casts[result.Movies[i].Id]
+	}
+
+	return result, nil
+}
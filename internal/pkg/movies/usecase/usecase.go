@@ -2,34 +2,42 @@ package usecase
 
 import (
 	"MovieService/internal/models"
+	"MovieService/internal/pkg/audit"
 	"MovieService/internal/pkg/movies"
+	"MovieService/internal/pkg/utils/etag"
+	"MovieService/internal/pkg/utils/logger"
+	"MovieService/internal/pkg/utils/tsquery"
+	"MovieService/internal/pkg/utils/validator"
 	"context"
-	"fmt"
-	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type MoviesUsecase struct {
-	repo movies.MoviesRepo
+	repo  movies.MoviesRepo
+	audit audit.Recorder
 }
 
-func NewMoviesUsecase(repo movies.MoviesRepo) *MoviesUsecase {
+func NewMoviesUsecase(repo movies.MoviesRepo, recorder audit.Recorder) *MoviesUsecase {
 	return &MoviesUsecase{
-		repo: repo,
+		repo:  repo,
+		audit: recorder,
 	}
 }
 
-func (mu MoviesUsecase) GetMovies(ctx context.Context, sortType string) ([]models.Movie, error) {
-	m, err := mu.repo.ReadMovies(ctx, sortType)
-	return m, err
+func (mu MoviesUsecase) GetMovies(ctx context.Context, filter *models.MovieFilter, sort []models.SortKey, page *models.PageRequest) (*models.MoviePage, error) {
+	return mu.repo.ReadMovies(ctx, filter, sort, page)
 }
 
 func (mu MoviesUsecase) AddMovie(ctx context.Context, movie *models.Movie) error {
+	if err := validator.Struct(movie); err != nil {
+		return err
+	}
+
 	movieId, err := mu.repo.CreateMovie(ctx, movie)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(movieId)
+	logger.FromContext(ctx).Debug("movie created", "id", movieId)
 	for _, actor := range movie.Actors {
 		err = mu.repo.AddActorToMovie(ctx, movieId, actor.Id)
 		if err != nil {
@@ -37,55 +45,134 @@ func (mu MoviesUsecase) AddMovie(ctx context.Context, movie *models.Movie) error
 		}
 	}
 
+	movie.Id = movieId
+	mu.audit.Record(ctx, audit.Entry{Action: "create", Entity: audit.EntityMovie, EntityId: movieId, After: movie})
 	return nil
 }
 
-func (mu MoviesUsecase) UpdateMovie(ctx context.Context, movie *models.Movie) error {
-	m, err := mu.repo.ReadMovie(ctx, movie.Id)
-	if movie.Name != "" {
-		m.Name = movie.Name
+// UpdateMovie replaces every field of the movie but the cast.
+func (mu MoviesUsecase) UpdateMovie(ctx context.Context, movie *models.Movie, cond etag.Precondition) (*models.Movie, error) {
+	before, err := mu.repo.ReadMovie(ctx, movie.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cond.Check(before); err != nil {
+		return nil, err
 	}
 
-	if movie.Description != "" {
-		m.Description = movie.Description
+	if err = validator.Struct(movie); err != nil {
+		return nil, err
+	}
+
+	movie.Version = before.Version
+
+	return mu.update(ctx, before, movie)
+}
+
+// PatchMovie changes only the fields present in the patch.
+func (mu MoviesUsecase) PatchMovie(ctx context.Context, id int, patch *models.MoviePatch, cond etag.Precondition) (*models.Movie, error) {
+	before, err := mu.repo.ReadMovie(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	if movie.Rating != 0 {
-		m.Rating = movie.Rating
+	if err = cond.Check(before); err != nil {
+		return nil, err
 	}
 
-	if movie.ReleaseDate != (pgtype.Date{}) {
-		m.ReleaseDate = movie.ReleaseDate
+	m := *before
+	patch.Apply(&m)
+	if err = validator.Struct(&m); err != nil {
+		return nil, err
 	}
 
-	err = mu.repo.UpdateMovie(ctx, m)
+	return mu.update(ctx, before, &m)
+}
+
+func (mu MoviesUsecase) update(ctx context.Context, before *models.Movie, m *models.Movie) (*models.Movie, error) {
+	err := mu.repo.UpdateMovie(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := mu.repo.ReadMovie(ctx, m.Id)
 	if err != nil {
+		return nil, err
+	}
+
+	mu.audit.Record(ctx, audit.Entry{Action: "update", Entity: audit.EntityMovie, EntityId: m.Id, Before: before, After: after})
+	return after, nil
+}
+
+func (mu MoviesUsecase) GetMovie(ctx context.Context, id int) (*models.Movie, error) {
+	return mu.repo.ReadMovie(ctx, id)
+}
+
+func (mu MoviesUsecase) DeleteMovie(ctx context.Context, id int, cond etag.Precondition) error {
+	before, err := mu.repo.ReadMovie(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = cond.Check(before); err != nil {
 		return err
 	}
+
+	err = mu.repo.DeleteMovie(ctx, id, before.Version)
+	if err != nil {
+		return err
+	}
+
+	mu.audit.Record(ctx, audit.Entry{Action: "delete", Entity: audit.EntityMovie, EntityId: id, Before: before})
 	return nil
 }
 
-func (mu MoviesUsecase) DeleteMovie(ctx context.Context, id int) error {
-	err := mu.repo.DeleteMovie(ctx, id)
-	return err
+func (mu MoviesUsecase) GetMoviesByMovieName(ctx context.Context, s string, sort []models.SortKey, page *models.PageRequest) (*models.MoviePage, error) {
+	return mu.repo.ReadMoviesByMovieName(ctx, s, sort, page)
 }
 
-func (mu MoviesUsecase) GetMoviesByMovieName(ctx context.Context, s string) ([]models.Movie, error) {
-	m, err := mu.repo.ReadMoviesByMovieName(ctx, s)
-	return m, err
+func (mu MoviesUsecase) GetMoviesByActorName(ctx context.Context, s string, sort []models.SortKey, page *models.PageRequest) (*models.MoviePage, error) {
+	return mu.repo.ReadMoviesByActorName(ctx, s, sort, page)
 }
 
-func (mu MoviesUsecase) GetMoviesByActorName(ctx context.Context, s string) ([]models.Movie, error) {
-	m, err := mu.repo.ReadMoviesByActorName(ctx, s)
-	return m, err
+// SearchMovies looks the query, in the syntax of tsquery.Parse, up in the
+// names and descriptions of the movies.
+func (mu MoviesUsecase) SearchMovies(ctx context.Context, q string, page *models.PageRequest) (*models.MovieSearchPage, error) {
+	query, err := tsquery.Parse(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return mu.repo.SearchMovies(ctx, query, page)
 }
 
 func (mu MoviesUsecase) AddActorToMovie(ctx context.Context, movieId int, actorId int) error {
 	err := mu.repo.AddActorToMovie(ctx, movieId, actorId)
-	return err
+	if err != nil {
+		return err
+	}
+
+	mu.audit.Record(ctx, audit.Entry{
+		Action:   "add_actor",
+		Entity:   audit.EntityMovie,
+		EntityId: movieId,
+		After:    map[string]int{"actorId": actorId},
+	})
+	return nil
 }
 
 func (mu MoviesUsecase) DeleteActorFromMovie(ctx context.Context, movieId int, actorId int) error {
 	err := mu.repo.DeleteActorFromMovie(ctx, movieId, actorId)
-	return err
+	if err != nil {
+		return err
+	}
+
+	mu.audit.Record(ctx, audit.Entry{
+		Action:   "remove_actor",
+		Entity:   audit.EntityMovie,
+		EntityId: movieId,
+		Before:   map[string]int{"actorId": actorId},
+	})
+	return nil
 }
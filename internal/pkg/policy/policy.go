@@ -0,0 +1,171 @@
+package policy
+
+import (
+	"MovieService/internal/models"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type Permission string
+
+const (
+	MoviesRead   Permission = "movies:read"
+	MoviesWrite  Permission = "movies:write"
+	MoviesDelete Permission = "movies:delete"
+	ActorsRead   Permission = "actors:read"
+	ActorsWrite  Permission = "actors:write"
+	ActorsDelete Permission = "actors:delete"
+	UsersRead    Permission = "users:read"
+	UsersWrite   Permission = "users:write"
+	UsersDelete  Permission = "users:delete"
+	AuditRead    Permission = "audit:read"
+
+	wildcard = "*"
+	// mfaKey lists, in the policy file, the roles that need a second factor
+	mfaKey = "mfa"
+)
+
+var permissions = []Permission{
+	MoviesRead, MoviesWrite, MoviesDelete,
+	ActorsRead, ActorsWrite, ActorsDelete,
+	UsersRead, UsersWrite, UsersDelete,
+	AuditRead,
+}
+
+var roleNames = map[string]models.Role{
+	"client": models.Client,
+	"admin":  models.Admin,
+}
+
+// defaultGrants is used when no policy file is configured. Grants are either
+// a permission, a "resource:*" pattern or "*" for everything.
+var defaultGrants = map[string][]string{
+	"client": {string(MoviesRead), string(ActorsRead)},
+	"admin":  {wildcard},
+}
+
+type Policy struct {
+	grants map[models.Role][]string
+	// mfa holds the roles whose permissions are granted only to sessions
+	// opened with a second factor
+	mfa map[models.Role]bool
+}
+
+func Default() *Policy {
+	p, _ := fromGrants(defaultGrants)
+	return p
+}
+
+// Load reads the role to permissions mapping from a JSON file like
+//
+//	{"client": ["movies:read", "actors:read"], "admin": ["*"], "mfa": ["admin"]}
+//
+// where the optional "mfa" entry lists the roles that require two-factor
+// authentication.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	grants := make(map[string][]string)
+	if err = json.Unmarshal(data, &grants); err != nil {
+		return nil, fmt.Errorf("error happened in json.Unmarshal: %w", err)
+	}
+
+	return fromGrants(grants)
+}
+
+func fromGrants(grants map[string][]string) (*Policy, error) {
+	p := &Policy{
+		grants: make(map[models.Role][]string, len(grants)),
+		mfa:    make(map[models.Role]bool),
+	}
+
+	for _, name := range grants[mfaKey] {
+		role, ok := roleNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown role %q", name)
+		}
+
+		p.mfa[role] = true
+	}
+
+	for name, perms := range grants {
+		if name == mfaKey {
+			continue
+		}
+
+		role, ok := roleNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown role %q", name)
+		}
+
+		p.grants[role] = perms
+	}
+
+	return p, nil
+}
+
+// Decide reports whether the role holds the permission and why.
+func (p *Policy) Decide(role models.Role, perm Permission) (bool, string) {
+	if grant, ok := Match(p.grants[role], perm); ok {
+		return true, fmt.Sprintf("role %s is granted %s", RoleName(role), grant)
+	}
+
+	return false, fmt.Sprintf("role %s is not granted %s", RoleName(role), perm)
+}
+
+// RequiresMFA reports whether the role acts only in multi-factor sessions.
+func (p *Policy) RequiresMFA(role models.Role) bool {
+	return p.mfa[role]
+}
+
+// Match returns the first of the grants that covers the permission.
+func Match(grants []string, perm Permission) (string, bool) {
+	resource, _, _ := strings.Cut(string(perm), ":")
+	for _, grant := range grants {
+		if grant == wildcard || grant == string(perm) || grant == resource+":"+wildcard {
+			return grant, true
+		}
+	}
+
+	return "", false
+}
+
+// Valid reports whether the grant is a known permission, a "resource:*"
+// pattern of a known resource or "*".
+func Valid(grant string) bool {
+	if grant == wildcard {
+		return true
+	}
+
+	for _, perm := range permissions {
+		resource, _, _ := strings.Cut(string(perm), ":")
+		if grant == string(perm) || grant == resource+":"+wildcard {
+			return true
+		}
+	}
+
+	return false
+}
+
+func RoleOf(isAdmin bool) models.Role {
+	if isAdmin {
+		return models.Admin
+	}
+
+	return models.Client
+}
+
+func RoleName(role models.Role) string {
+	for name, r := range roleNames {
+		if r == role {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("role(%d)", role)
+}
@@ -0,0 +1,89 @@
+package etag
+
+import (
+	"MovieService/internal/pkg/apperr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+var ErrMismatch = apperr.PreconditionFailed("etag_mismatch", "the resource has changed, fetch it again")
+
+// Of returns the strong entity tag of the JSON representation of v. The tag
+// covers everything the client sees, the cast of a movie included, so it
+// changes whenever the response body does.
+func Of(v any) string {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Precondition is the If-Match header of a request, empty when there is none.
+type Precondition string
+
+func IfMatch(r *http.Request) Precondition {
+	return Precondition(strings.Join(r.Header.Values("If-Match"), ","))
+}
+
+// Check fails with ErrMismatch unless the precondition is absent, "*" or
+// lists the tag of the current representation. If-Match uses the strong
+// comparison, weak tags never match.
+func (p Precondition) Check(current any) error {
+	if p == "" || matches(string(p), Of(current), false) {
+		return nil
+	}
+
+	return ErrMismatch
+}
+
+// NotModified sets the ETag of v and, when the If-None-Match header of the
+// request lists it, answers 304 and reports true.
+func NotModified(w http.ResponseWriter, r *http.Request, v any) bool {
+	tag := Of(v)
+	if tag != "" {
+		w.Header().Set("ETag", tag)
+	}
+
+	header := strings.Join(r.Header.Values("If-None-Match"), ",")
+	if tag == "" || header == "" || !matches(header, tag, true) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// Set sets the ETag of v on the response.
+func Set(w http.ResponseWriter, v any) {
+	if tag := Of(v); tag != "" {
+		w.Header().Set("ETag", tag)
+	}
+}
+
+func matches(header string, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+
+		if candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
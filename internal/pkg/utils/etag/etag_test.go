@@ -0,0 +1,115 @@
+package etag
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type movie struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Actors []int  `json:"actors"`
+}
+
+func TestOf(t *testing.T) {
+	m := movie{Id: 1, Name: "Alien", Actors: []int{1, 2}}
+	tag := Of(m)
+	if len(tag) != 34 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		t.Fatalf("Of = %s, want a quoted strong tag", tag)
+	}
+	if Of(m) != tag {
+		t.Error("Of is not stable")
+	}
+
+	m.Actors = []int{1}
+	if Of(m) == tag {
+		t.Error("Of did not change with the cast")
+	}
+	if Of(func() {}) != "" {
+		t.Error("Of of an unencodable value is not empty")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	current := movie{Id: 1, Name: "Alien"}
+	tag := Of(current)
+	other := Of(movie{Id: 1, Name: "Aliens"})
+
+	tests := []struct {
+		name    string
+		ifMatch []string
+		ok      bool
+	}{
+		{name: "no header", ok: true},
+		{name: "same tag", ifMatch: []string{tag}, ok: true},
+		{name: "any", ifMatch: []string{"*"}, ok: true},
+		{name: "listed", ifMatch: []string{other + ", " + tag}, ok: true},
+		{name: "repeated header", ifMatch: []string{other, tag}, ok: true},
+		{name: "other tag", ifMatch: []string{other}},
+		{name: "weak tag", ifMatch: []string{"W/" + tag}},
+		{name: "unquoted tag", ifMatch: []string{tag[1 : len(tag)-1]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/api/movies/1", nil)
+			for _, v := range tt.ifMatch {
+				r.Header.Add("If-Match", v)
+			}
+
+			err := IfMatch(r).Check(current)
+			if tt.ok && err != nil {
+				t.Errorf("Check = %v, want nil", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrMismatch) {
+				t.Errorf("Check = %v, want ErrMismatch", err)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	current := movie{Id: 1, Name: "Alien"}
+	tag := Of(current)
+	other := Of(movie{Id: 2})
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		notModified bool
+	}{
+		{name: "no header"},
+		{name: "same tag", ifNoneMatch: tag, notModified: true},
+		{name: "weak tag", ifNoneMatch: "W/" + tag, notModified: true},
+		{name: "any", ifNoneMatch: "*", notModified: true},
+		{name: "listed", ifNoneMatch: other + ", " + tag, notModified: true},
+		{name: "other tag", ifNoneMatch: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/movies/1", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+
+			if got := NotModified(w, r, current); got != tt.notModified {
+				t.Fatalf("NotModified = %v, want %v", got, tt.notModified)
+			}
+			if got := w.Header().Get("ETag"); got != tag {
+				t.Errorf("ETag = %s, want %s", got, tag)
+			}
+
+			status := http.StatusOK
+			if tt.notModified {
+				status = http.StatusNotModified
+			}
+			if w.Code != status {
+				t.Errorf("status = %d, want %d", w.Code, status)
+			}
+		})
+	}
+}
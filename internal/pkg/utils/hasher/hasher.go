@@ -0,0 +1,165 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+	// unusablePrefix starts the stored password of an account no password
+	// signs in to, it is never the start of a hash
+	unusablePrefix = "!"
+)
+
+var (
+	ErrInvalidHash   = errors.New("invalid password hash")
+	ErrInvalidParams = errors.New("invalid argon2id parameters")
+)
+
+// Unusable returns the stored password of an account that cannot sign in with
+// a password, e.g. one waiting for a reset or signing in through SSO only. The
+// reason only tells operators why.
+func Unusable(reason string) string {
+	return unusablePrefix + reason
+}
+
+// Usable reports whether a password can match the stored one.
+func Usable(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (match bool, needsRehash bool, err error)
+}
+
+// Params are the argon2id cost parameters. They are stored alongside every
+// hash, so raising them only affects new hashes and the ones rehashed on login.
+type Params struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+var DefaultParams = Params{
+	Memory:  64 * 1024,
+	Time:    3,
+	Threads: 2,
+	SaltLen: 16,
+	KeyLen:  32,
+}
+
+// Validate rejects the parameters argon2id cannot work with; a zero time or
+// number of threads makes it panic.
+func (p Params) Validate() error {
+	switch {
+	case p.Time < 1:
+		return fmt.Errorf("%w: time must be at least 1", ErrInvalidParams)
+	case p.Threads < 1:
+		return fmt.Errorf("%w: threads must be at least 1", ErrInvalidParams)
+	case p.Memory < 8*uint32(p.Threads):
+		return fmt.Errorf("%w: memory must be at least 8 KiB per thread", ErrInvalidParams)
+	case p.SaltLen < 8:
+		return fmt.Errorf("%w: salt must be at least 8 bytes", ErrInvalidParams)
+	case p.KeyLen < 16:
+		return fmt.Errorf("%w: key must be at least 16 bytes", ErrInvalidParams)
+	}
+
+	return nil
+}
+
+type Argon2Hasher struct {
+	params Params
+}
+
+func NewArgon2Hasher(params Params) (*Argon2Hasher, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Argon2Hasher{
+		params: params,
+	}, nil
+}
+
+// Hash returns the password encoded in the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func (h *Argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Time, h.params.Memory, h.params.Threads, h.params.KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.params.Memory,
+		h.params.Time,
+		h.params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify checks the password against an encoded hash. needsRehash is set when
+// the hash was made with other parameters than the current ones. Anything but
+// an argon2id hash never matches: empty and unusable values as well as legacy
+// plaintext passwords, which are hashed once at startup instead.
+func (h *Argon2Hasher) Verify(password, encoded string) (bool, bool, error) {
+	if !Usable(encoded) {
+		return false, false, nil
+	}
+
+	params, salt, key, err := decode(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return false, false, nil
+	}
+
+	return true, params != h.params, nil
+}
+
+func decode(encoded string) (Params, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+
+	p := Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	p.SaltLen = uint32(len(salt))
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	p.KeyLen = uint32(len(key))
+
+	return p, salt, key, nil
+}
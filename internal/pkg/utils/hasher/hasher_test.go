@@ -0,0 +1,141 @@
+package hasher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testParams = Params{Memory: 64, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32}
+
+func newTestHasher(t *testing.T, params Params) *Argon2Hasher {
+	t.Helper()
+
+	h, err := NewArgon2Hasher(params)
+	if err != nil {
+		t.Fatalf("NewArgon2Hasher: %v", err)
+	}
+
+	return h
+}
+
+func TestHashVerify(t *testing.T) {
+	h := newTestHasher(t, testParams)
+
+	encoded, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("Hash = %q, want the PHC format with the parameters", encoded)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		encoded  string
+		match    bool
+	}{
+		{"same password", "correct horse", encoded, true},
+		{"other password", "battery staple", encoded, false},
+		{"empty password", "", encoded, false},
+		{"empty stored value", "", "", false},
+		{"unusable stored value", "!reset", Unusable("reset"), false},
+		{"empty password against unusable", "", Unusable("sso"), false},
+		{"legacy plaintext", "secret", "secret", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, needsRehash, err := h.Verify(tt.password, tt.encoded)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if match != tt.match {
+				t.Errorf("match = %v, want %v", match, tt.match)
+			}
+			if needsRehash {
+				t.Errorf("needsRehash = true, want false")
+			}
+		})
+	}
+}
+
+func TestHashesAreSalted(t *testing.T) {
+	h := newTestHasher(t, testParams)
+
+	first, _ := h.Hash("password")
+	second, _ := h.Hash("password")
+	if first == second {
+		t.Errorf("two hashes of the same password are equal: %q", first)
+	}
+}
+
+func TestVerifyNeedsRehash(t *testing.T) {
+	old := newTestHasher(t, testParams)
+	encoded, err := old.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	stronger := testParams
+	stronger.Time = 2
+	h := newTestHasher(t, stronger)
+
+	match, needsRehash, err := h.Verify("password", encoded)
+	if err != nil || !match || !needsRehash {
+		t.Errorf("Verify = %v, %v, %v; want a match that needs a rehash", match, needsRehash, err)
+	}
+
+	rehashed, _ := h.Hash("password")
+	match, needsRehash, err = h.Verify("password", rehashed)
+	if err != nil || !match || needsRehash {
+		t.Errorf("Verify after rehash = %v, %v, %v; want a match with no rehash", match, needsRehash, err)
+	}
+}
+
+func TestVerifyInvalidHash(t *testing.T) {
+	h := newTestHasher(t, testParams)
+
+	for _, encoded := range []string{
+		"$argon2id$",
+		"$argon2id$v=19$m=64,t=1,p=1$salt",
+		"$argon2id$v=18$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5",
+	} {
+		if _, _, err := h.Verify("password", encoded); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("Verify(%q) error = %v, want ErrInvalidHash", encoded, err)
+		}
+	}
+}
+
+func TestParamsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Params)
+		valid  bool
+	}{
+		{"defaults", func(*Params) {}, true},
+		{"zero time", func(p *Params) { p.Time = 0 }, false},
+		{"zero threads", func(p *Params) { p.Threads = 0 }, false},
+		{"memory below 8 KiB per thread", func(p *Params) { p.Memory = 8*uint32(p.Threads) - 1 }, false},
+		{"short salt", func(p *Params) { p.SaltLen = 4 }, false},
+		{"short key", func(p *Params) { p.KeyLen = 8 }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := DefaultParams
+			tt.modify(&params)
+
+			_, err := NewArgon2Hasher(params)
+			if tt.valid && err != nil {
+				t.Errorf("NewArgon2Hasher: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidParams) {
+				t.Errorf("NewArgon2Hasher error = %v, want ErrInvalidParams", err)
+			}
+		})
+	}
+}
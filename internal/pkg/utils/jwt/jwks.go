@@ -0,0 +1,54 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwk returns the public part of the key. HS256 secrets are never published.
+func (k *Key) jwk() (JWK, bool) {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: k.Id,
+			Alg: k.Method.Alg(),
+			Use: "sig",
+			N:   b64(pub.N.Bytes()),
+			E:   b64(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: k.Id,
+			Alg: k.Method.Alg(),
+			Use: "sig",
+			Crv: "Ed25519",
+			X:   b64(pub),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
@@ -0,0 +1,119 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Key is an entry of the keyring. Keys without a private part only verify
+// tokens, which is how a retired signing key is kept until the tokens it
+// signed expire.
+type Key struct {
+	Id     string
+	Method jwt.SigningMethod
+	// Private is the []byte secret for HS256, *rsa.PrivateKey for RS256 and
+	// ed25519.PrivateKey for EdDSA.
+	Private crypto.PrivateKey
+	// Public is the []byte secret for HS256, *rsa.PublicKey for RS256 and
+	// ed25519.PublicKey for EdDSA.
+	Public crypto.PublicKey
+}
+
+func (k *Key) canSign() bool {
+	return k.Private != nil
+}
+
+func NewHMACKey(id string, secret []byte) (*Key, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("empty signing key")
+	}
+
+	return &Key{Id: id, Method: jwt.SigningMethodHS256, Private: secret, Public: secret}, nil
+}
+
+// ParsePEMKey reads an RSA or Ed25519 key, private (PKCS#1 or PKCS#8) or
+// public (PKIX).
+func ParsePEMKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %v: no PEM data", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %v: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %v: %w", id, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{Id: id, Method: jwt.SigningMethodRS256, Private: k, Public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{Id: id, Method: jwt.SigningMethodRS256, Public: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{Id: id, Method: jwt.SigningMethodEdDSA, Private: k, Public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{Id: id, Method: jwt.SigningMethodEdDSA, Public: k}, nil
+	default:
+		return nil, fmt.Errorf("key %v: unsupported key type %T", id, parsed)
+	}
+}
+
+// LoadKeyDir loads every key of the directory, the file name without the
+// extension being the kid: "<kid>.pem" holds an RSA or Ed25519 key and
+// "<kid>.key" an HS256 secret.
+func LoadKeyDir(dir string) ([]*Key, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(entries))
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".pem" && ext != ".key") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		id := strings.TrimSuffix(entry.Name(), ext)
+		var key *Key
+		if ext == ".pem" {
+			key, err = ParsePEMKey(id, data)
+		} else {
+			key, err = NewHMACKey(id, []byte(strings.TrimSpace(string(data))))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
@@ -2,69 +2,163 @@ package jwt
 
 import (
 	"MovieService/internal/models"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
-	"math/rand"
 	"time"
 )
 
+const (
+	DefaultAccessTTL = 15 * time.Minute
+	DefaultIssuer    = "MovieService"
+	DefaultAudience  = "MovieService"
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+	ErrUnexpectedMethod = errors.New("unexpected signing method")
+)
+
 type TokenManager interface {
-	NewJWT(userId int, isAdmin bool) (string, error)
-	Parse(accessToken string) (int, bool, error)
+	NewJWT(claims *models.JwtClaims) (string, error)
+	Parse(accessToken string) (*models.JwtClaims, error)
 	NewRefreshToken() (string, error)
+	// JWKS returns the public keys tokens can be verified with.
+	JWKS() *JWKSet
+}
+
+type Config struct {
+	// Keys are all keys tokens are accepted from. SigningKeyId picks the one
+	// new tokens are signed with, it must have a private part.
+	Keys         []*Key
+	SigningKeyId string
+	Issuer       string
+	Audience     string
+	AccessTTL    time.Duration
 }
 
 type Manager struct {
-	signingKey string
+	keys      map[string]*Key
+	signing   *Key
+	issuer    string
+	audience  string
+	accessTTL time.Duration
+	jwks      *JWKSet
 }
 
-var TokenManagerSingletone *Manager
+func NewManager(cfg Config) (*Manager, error) {
+	m := &Manager{
+		keys:      make(map[string]*Key, len(cfg.Keys)),
+		issuer:    cfg.Issuer,
+		audience:  cfg.Audience,
+		accessTTL: cfg.AccessTTL,
+		jwks:      &JWKSet{Keys: make([]JWK, 0, len(cfg.Keys))},
+	}
 
-func LoadSecret(signingKey string) error {
-	if signingKey == "" {
-		return errors.New("empty signing key")
+	if m.accessTTL <= 0 {
+		m.accessTTL = DefaultAccessTTL
 	}
 
-	TokenManagerSingletone = &Manager{signingKey: signingKey}
-	return nil
-}
+	for _, key := range cfg.Keys {
+		if _, ok := m.keys[key.Id]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.Id)
+		}
+		m.keys[key.Id] = key
 
-func (m *Manager) NewJWT(userId int, isAdmin bool) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &models.JwtClaims{
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(5 * time.Hour).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-		UserId:  userId,
-		IsAdmin: isAdmin,
-	})
-
-	return token.SignedString([]byte(m.signingKey))
+		if jwk, ok := key.jwk(); ok {
+			m.jwks.Keys = append(m.jwks.Keys, jwk)
+		}
+	}
+
+	signing, ok := m.keys[cfg.SigningKeyId]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q is not in the keyring", cfg.SigningKeyId)
+	}
+	if !signing.canSign() {
+		return nil, fmt.Errorf("signing key %q has no private part", cfg.SigningKeyId)
+	}
+	m.signing = signing
+
+	return m, nil
 }
 
-func (m *Manager) Parse(accessToken string) (int, bool, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &models.JwtClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(m.signingKey), nil
-	})
+// NewJWT signs the claims. The registered claims (jti, iss, aud, iat, nbf,
+// exp) are filled in place, so the caller can read the id and expiry of the
+// issued token.
+func (m *Manager) NewJWT(claims *models.JwtClaims) (string, error) {
+	jti, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
 
+	now := time.Now()
+	claims.StandardClaims = jwt.StandardClaims{
+		Id:        jti,
+		Issuer:    m.issuer,
+		Audience:  m.audience,
+		ExpiresAt: now.Add(m.accessTTL).Unix(),
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+	}
+
+	token := jwt.NewWithClaims(m.signing.Method, claims)
+	token.Header["kid"] = m.signing.Id
+
+	return token.SignedString(m.signing.Private)
+}
+
+// Parse verifies the token with the key named by its kid header. exp, nbf and
+// iat are checked by the claims validation, iss and aud against the config.
+func (m *Manager) Parse(accessToken string) (*models.JwtClaims, error) {
+	token, err := jwt.ParseWithClaims(accessToken, &models.JwtClaims{}, m.verificationKey)
 	if err != nil {
-		return 0, false, err
+		return nil, err
+	}
+
+	claims := token.Claims.(*models.JwtClaims)
+	if !claims.VerifyIssuer(m.issuer, m.issuer != "") {
+		return nil, ErrInvalidIssuer
+	}
+	if !claims.VerifyAudience(m.audience, m.audience != "") {
+		return nil, ErrInvalidAudience
+	}
+
+	return claims, nil
+}
+
+func (m *Manager) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := m.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	// The algorithm is bound to the key, never taken from the token alone.
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrUnexpectedMethod
 	}
 
-	myClaims := token.Claims.(*models.JwtClaims)
-	return myClaims.UserId, myClaims.IsAdmin, nil
+	return key.Public, nil
+}
+
+func (m *Manager) JWKS() *JWKSet {
+	return m.jwks
 }
 
 func (m *Manager) NewRefreshToken() (string, error) {
-	b := make([]byte, 32)
+	return randomString(32)
+}
 
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
 
-	if _, err := r.Read(b); err != nil {
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("%x", b), nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
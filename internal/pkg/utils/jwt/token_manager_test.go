@@ -0,0 +1,375 @@
+package jwt
+
+import (
+	"MovieService/internal/models"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	rsaKey, _       = rsa.GenerateKey(rand.Reader, 2048)
+	edPublic, edKey = mustEd25519()
+)
+
+func mustEd25519() (ed25519.PublicKey, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	return pub, priv
+}
+
+func rsaSigningKey(id string) *Key {
+	return &Key{Id: id, Method: jwt.SigningMethodRS256, Private: rsaKey, Public: &rsaKey.PublicKey}
+}
+
+func edSigningKey(id string) *Key {
+	return &Key{Id: id, Method: jwt.SigningMethodEdDSA, Private: edKey, Public: edPublic}
+}
+
+func hmacKey(t *testing.T, id string) *Key {
+	t.Helper()
+
+	key, err := NewHMACKey(id, []byte("a secret of the HS256 key"))
+	if err != nil {
+		t.Fatalf("NewHMACKey: %v", err)
+	}
+
+	return key
+}
+
+func newManager(t *testing.T, signingKeyId string, keys ...*Key) *Manager {
+	t.Helper()
+
+	m, err := NewManager(Config{Keys: keys, SigningKeyId: signingKeyId, Issuer: DefaultIssuer, Audience: DefaultAudience})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	return m
+}
+
+// sign makes a token the way another party could, with any header and claims.
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims *models.JwtClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	return signed
+}
+
+func validClaims() *models.JwtClaims {
+	now := time.Now()
+	return &models.JwtClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        "jti",
+			Issuer:    DefaultIssuer,
+			Audience:  DefaultAudience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(time.Minute).Unix(),
+		},
+		UserId: 7,
+	}
+}
+
+// cause is the error the key lookup or the manager returned, unwrapped from
+// the validation error of the jwt package.
+func cause(err error) error {
+	var validationErr *jwt.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Inner != nil {
+		return validationErr.Inner
+	}
+
+	return err
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, key := range []*Key{rsaSigningKey("rsa"), edSigningKey("ed"), hmacKey(t, "hs")} {
+		t.Run(key.Method.Alg(), func(t *testing.T) {
+			m := newManager(t, key.Id, key)
+
+			claims := &models.JwtClaims{UserId: 7, IsAdmin: true, SessionId: 3, Mfa: true}
+			token, err := m.NewJWT(claims)
+			if err != nil {
+				t.Fatalf("NewJWT: %v", err)
+			}
+
+			parsed, err := m.Parse(token)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			if parsed.Id == "" || parsed.Id != claims.Id {
+				t.Errorf("jti = %q, want %q", parsed.Id, claims.Id)
+			}
+			if parsed.UserId != 7 || !parsed.IsAdmin || parsed.SessionId != 3 || !parsed.Mfa {
+				t.Errorf("claims = %+v, want those of the token", parsed)
+			}
+			if parsed.Issuer != DefaultIssuer || parsed.Audience != DefaultAudience {
+				t.Errorf("iss, aud = %q, %q", parsed.Issuer, parsed.Audience)
+			}
+		})
+	}
+}
+
+func TestParseLooksTheKeyUpByKid(t *testing.T) {
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	m := newManager(t, "a",
+		rsaSigningKey("a"),
+		&Key{Id: "b", Method: jwt.SigningMethodRS256, Private: other, Public: &other.PublicKey})
+
+	tests := []struct {
+		name    string
+		kid     string
+		key     interface{}
+		wantErr error
+	}{
+		{name: "first key", kid: "a", key: rsaKey},
+		{name: "second key", kid: "b", key: other},
+		{name: "kid of another key", kid: "b", key: rsaKey, wantErr: rsa.ErrVerification},
+		{name: "unknown kid", kid: "c", key: rsaKey, wantErr: ErrUnknownKey},
+		{name: "no kid", kid: "", key: rsaKey, wantErr: ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Parse(sign(t, jwt.SigningMethodRS256, tt.kid, tt.key, validClaims()))
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(cause(err), tt.wantErr) {
+				t.Errorf("Parse error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseBindsTheAlgorithmToTheKey(t *testing.T) {
+	hs := hmacKey(t, "hs")
+	m := newManager(t, "rsa", rsaSigningKey("rsa"), edSigningKey("ed"), hs)
+
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims())
+	none.Header["kid"] = "rsa"
+	unsigned, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		// the public key is no secret, an HS256 token made with it must not
+		// pass for one of the RSA key
+		{"HS256 with the RSA public key as secret", sign(t, jwt.SigningMethodHS256, "rsa", publicPEM, validClaims())},
+		{"RS256 under the kid of the HS256 key", sign(t, jwt.SigningMethodRS256, "hs", rsaKey, validClaims())},
+		{"RS256 under the kid of the Ed25519 key", sign(t, jwt.SigningMethodRS256, "ed", rsaKey, validClaims())},
+		{"EdDSA under the kid of the RSA key", sign(t, jwt.SigningMethodEdDSA, "rsa", edKey, validClaims())},
+		{"HS256 under the kid of the RSA key", sign(t, jwt.SigningMethodHS256, "rsa", hs.Private, validClaims())},
+		{"none", unsigned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Parse(tt.token); !errors.Is(cause(err), ErrUnexpectedMethod) {
+				t.Errorf("Parse error = %v, want ErrUnexpectedMethod", err)
+			}
+		})
+	}
+}
+
+func TestParseChecksTheRegisteredClaims(t *testing.T) {
+	m := newManager(t, "rsa", rsaSigningKey("rsa"))
+	later := time.Now().Add(time.Hour).Unix()
+	earlier := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name    string
+		modify  func(c *models.JwtClaims)
+		wantErr error
+	}{
+		{"other issuer", func(c *models.JwtClaims) { c.Issuer = "SomeoneElse" }, ErrInvalidIssuer},
+		{"no issuer", func(c *models.JwtClaims) { c.Issuer = "" }, ErrInvalidIssuer},
+		{"other audience", func(c *models.JwtClaims) { c.Audience = "SomeoneElse" }, ErrInvalidAudience},
+		{"no audience", func(c *models.JwtClaims) { c.Audience = "" }, ErrInvalidAudience},
+		{"not valid yet", func(c *models.JwtClaims) { c.NotBefore = later }, nil},
+		{"issued in the future", func(c *models.JwtClaims) { c.IssuedAt = later }, nil},
+		{"expired", func(c *models.JwtClaims) { c.ExpiresAt = earlier }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.modify(claims)
+
+			_, err := m.Parse(sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims))
+			if err == nil {
+				t.Fatal("Parse accepted the token")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old := newManager(t, "2024", rsaSigningKey("2024"))
+	token, err := old.NewJWT(&models.JwtClaims{UserId: 7})
+	if err != nil {
+		t.Fatalf("NewJWT: %v", err)
+	}
+
+	// the old key is retired: it only verifies until its tokens expire
+	retired := &Key{Id: "2024", Method: jwt.SigningMethodRS256, Public: &rsaKey.PublicKey}
+	m := newManager(t, "2025", edSigningKey("2025"), retired)
+
+	if _, err = m.Parse(token); err != nil {
+		t.Errorf("token of the retired key: %v", err)
+	}
+
+	fresh, err := m.NewJWT(&models.JwtClaims{UserId: 7})
+	if err != nil {
+		t.Fatalf("NewJWT: %v", err)
+	}
+	parsed, err := jwt.Parse(fresh, func(token *jwt.Token) (interface{}, error) { return edPublic, nil })
+	if err != nil || parsed.Header["kid"] != "2025" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("new tokens are not signed with the new key: %v, %v", parsed.Header, err)
+	}
+
+	// once dropped from the keyring, its tokens are rejected
+	m = newManager(t, "2025", edSigningKey("2025"))
+	if _, err = m.Parse(token); !errors.Is(cause(err), ErrUnknownKey) {
+		t.Errorf("token of a dropped key: %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	retired := &Key{Id: "old", Method: jwt.SigningMethodRS256, Public: &rsaKey.PublicKey}
+	m := newManager(t, "rsa", rsaSigningKey("rsa"), edSigningKey("ed"), hmacKey(t, "hs"), retired)
+
+	keys := make(map[string]JWK)
+	for _, k := range m.JWKS().Keys {
+		keys[k.Kid] = k
+	}
+
+	if _, ok := keys["hs"]; ok || len(keys) != 3 {
+		t.Fatalf("JWKS has keys %v, want rsa, ed and old without the HMAC key", keys)
+	}
+
+	for _, kid := range []string{"rsa", "old"} {
+		k := keys[kid]
+		n, _ := base64.RawURLEncoding.DecodeString(k.N)
+		e, _ := base64.RawURLEncoding.DecodeString(k.E)
+		if k.Kty != "RSA" || k.Alg != "RS256" || k.Use != "sig" ||
+			new(big.Int).SetBytes(n).Cmp(rsaKey.N) != 0 || new(big.Int).SetBytes(e).Int64() != int64(rsaKey.E) {
+			t.Errorf("JWK %s = %+v, want the RSA public key", kid, k)
+		}
+	}
+
+	ed := keys["ed"]
+	x, _ := base64.RawURLEncoding.DecodeString(ed.X)
+	if ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.Alg != "EdDSA" || !edPublic.Equal(ed25519.PublicKey(x)) {
+		t.Errorf("JWK ed = %+v, want the Ed25519 public key", ed)
+	}
+}
+
+func TestNewManagerRejects(t *testing.T) {
+	retired := &Key{Id: "old", Method: jwt.SigningMethodRS256, Public: &rsaKey.PublicKey}
+	tests := []struct {
+		name    string
+		keys    []*Key
+		signing string
+	}{
+		{"duplicate kid", []*Key{rsaSigningKey("a"), edSigningKey("a")}, "a"},
+		{"signing key not in the keyring", []*Key{rsaSigningKey("a")}, "b"},
+		{"signing key without private part", []*Key{retired}, "old"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewManager(Config{Keys: tt.keys, SigningKeyId: tt.signing}); err == nil {
+				t.Error("NewManager accepted the keyring")
+			}
+		})
+	}
+}
+
+func TestLoadKeyDir(t *testing.T) {
+	dir := t.TempDir()
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+
+	files := map[string][]byte{
+		"ed.pem":    pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+		"rsa.pem":   pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+		"old.pem":   pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}),
+		"hs.key":    []byte("a secret\n"),
+		"README.md": []byte("not a key"),
+	}
+	for name, data := range files {
+		if err = os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	keys, err := LoadKeyDir(dir)
+	if err != nil {
+		t.Fatalf("LoadKeyDir: %v", err)
+	}
+
+	want := map[string]struct {
+		alg     string
+		canSign bool
+	}{
+		"ed":  {"EdDSA", true},
+		"rsa": {"RS256", true},
+		"old": {"RS256", false},
+		"hs":  {"HS256", true},
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("loaded %d keys, want %d", len(keys), len(want))
+	}
+	for _, key := range keys {
+		w, ok := want[key.Id]
+		if !ok || key.Method.Alg() != w.alg || key.canSign() != w.canSign {
+			t.Errorf("key %s = %s, can sign: %v", key.Id, key.Method.Alg(), key.canSign())
+		}
+		if secret, ok := key.Private.([]byte); key.Id == "hs" && (!ok || string(secret) != "a secret") {
+			t.Errorf("HS256 secret = %q, want it trimmed", key.Private)
+		}
+	}
+
+	if _, err = ParsePEMKey("bad", []byte("not PEM")); err == nil {
+		t.Error("ParsePEMKey accepted data without PEM")
+	}
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5/tracelog"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type ctxKey int
+
+const (
+	loggerKey ctxKey = iota
+)
+
+// New builds a logger writing in the format ("text" or "json") at the level
+// ("debug", "info", "warn" or "error").
+func New(w io.Writer, format string, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// WithContext stores the logger of the request, usually one already carrying
+// the request id.
+func WithContext(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, log)
+}
+
+// FromContext returns the logger of the request, or the default one outside
+// of requests.
+func FromContext(ctx context.Context) *slog.Logger {
+	if log, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return log
+	}
+
+	return slog.Default()
+}
+
+// Tracer logs the queries of the repos with the logger of the request they
+// belong to. Successful queries are logged at debug level; arguments are left
+// out, they hold password hashes and tokens.
+func Tracer() *tracelog.TraceLog {
+	return &tracelog.TraceLog{
+		Logger:   tracelog.LoggerFunc(logQuery),
+		LogLevel: tracelog.LogLevelInfo,
+	}
+}
+
+func logQuery(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]any) {
+	lvl := slog.LevelDebug
+	if level <= tracelog.LogLevelError {
+		lvl = slog.LevelError
+	} else if level == tracelog.LogLevelWarn {
+		lvl = slog.LevelWarn
+	}
+
+	log := FromContext(ctx)
+	if !log.Enabled(ctx, lvl) {
+		return
+	}
+
+	attrs := make([]any, 0, 2*len(data))
+	for k, v := range data {
+		if k == "args" {
+			continue
+		}
+		attrs = append(attrs, k, v)
+	}
+
+	log.Log(ctx, lvl, "db: "+msg, attrs...)
+}
@@ -0,0 +1,62 @@
+package mergepatch
+
+import (
+	"MovieService/internal/pkg/apperr"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// ContentType is the media type of a JSON merge patch, RFC 7396.
+const ContentType = "application/merge-patch+json"
+
+var ErrMediaType = apperr.UnsupportedMediaType("unsupported_media_type", "PATCH expects "+ContentType)
+
+// Field is a field of a merge patch. Set tells a field present in the patch
+// from an absent one; null clears the field to the zero value.
+type Field[T any] struct {
+	Value T
+	Set   bool
+}
+
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if bytes.Equal(data, []byte("null")) {
+		var zero T
+		f.Value = zero
+		return nil
+	}
+
+	return json.Unmarshal(data, &f.Value)
+}
+
+// Apply overwrites dst with the value of the field when the patch has it.
+func (f Field[T]) Apply(dst *T) {
+	if f.Set {
+		*dst = f.Value
+	}
+}
+
+// Decode reads the merge patch of the request into patch, a struct of Fields.
+// Members the struct does not know are rejected, so that a typo or a read-only
+// field is not silently dropped.
+func Decode(r *http.Request, patch any) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != ContentType {
+		return ErrMediaType
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(patch); err != nil {
+		if err == io.EOF {
+			return apperr.BadRequest("invalid_patch", "request body is empty")
+		}
+
+		return apperr.BadRequest("invalid_patch", "invalid merge patch: "+err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package mergepatch
+
+import (
+	"MovieService/internal/pkg/apperr"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type moviePatch struct {
+	Name   Field[string]   `json:"name"`
+	Rating Field[*float64] `json:"rating"`
+	Actors Field[[]int]    `json:"actors"`
+}
+
+func TestField(t *testing.T) {
+	rating := 7.5
+	tests := []struct {
+		name   string
+		body   string
+		want   string
+		rating *float64
+		actors []int
+	}{
+		{name: "absent keeps the value", body: `{}`, want: "Alien", rating: &rating, actors: []int{1}},
+		{name: "value replaces it", body: `{"name":"Aliens","actors":[2,3]}`, want: "Aliens", rating: &rating, actors: []int{2, 3}},
+		{name: "null clears it", body: `{"name":null,"rating":null,"actors":null}`, want: "", rating: nil, actors: nil},
+		{name: "empty value", body: `{"name":"","actors":[]}`, want: "", rating: &rating, actors: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch moviePatch
+			if err := Decode(request(ContentType, tt.body), &patch); err != nil {
+				t.Fatalf("Decode = %v", err)
+			}
+
+			name, r, actors := "Alien", &rating, []int{1}
+			patch.Name.Apply(&name)
+			patch.Rating.Apply(&r)
+			patch.Actors.Apply(&actors)
+
+			if name != tt.want {
+				t.Errorf("name = %q, want %q", name, tt.want)
+			}
+			if r != tt.rating {
+				t.Errorf("rating = %v, want %v", r, tt.rating)
+			}
+			if (actors == nil) != (tt.actors == nil) || len(actors) != len(tt.actors) {
+				t.Errorf("actors = %#v, want %#v", actors, tt.actors)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		kind        error
+	}{
+		{name: "charset parameter", contentType: ContentType + "; charset=utf-8", body: `{"name":"x"}`},
+		{name: "plain json", contentType: "application/json", body: `{"name":"x"}`, kind: apperr.ErrMediaType},
+		{name: "no content type", contentType: "", body: `{"name":"x"}`, kind: apperr.ErrMediaType},
+		{name: "empty body", contentType: ContentType, body: ``, kind: apperr.ErrBadRequest},
+		{name: "unknown member", contentType: ContentType, body: `{"id":3}`, kind: apperr.ErrBadRequest},
+		{name: "wrong type", contentType: ContentType, body: `{"name":3}`, kind: apperr.ErrBadRequest},
+		{name: "not an object", contentType: ContentType, body: `[]`, kind: apperr.ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch moviePatch
+			err := Decode(request(tt.contentType, tt.body), &patch)
+			if tt.kind == nil {
+				if err != nil {
+					t.Fatalf("Decode = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("Decode = %v, want %v", err, tt.kind)
+			}
+		})
+	}
+}
+
+func request(contentType string, body string) *http.Request {
+	r := httptest.NewRequest("PATCH", "/api/movies/1", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
@@ -0,0 +1,331 @@
+package oidc
+
+import (
+	"MovieService/internal/models"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	// the JWKS is fetched again on an unknown kid, but not more often
+	jwksMinRefresh = time.Minute
+)
+
+var (
+	ErrInvalidIdToken = errors.New("invalid ID token")
+	ErrExchange       = errors.New("authorization code exchange failed")
+)
+
+type Config struct {
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// GroupsClaim is the ID token claim with the groups of the user.
+	GroupsClaim string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// Provider runs the authorization code flow with PKCE against an OpenID
+// Connect issuer. The discovery document and the keys are fetched on first
+// use, so the service starts even when the issuer is down.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.Mutex
+	discovery   *discovery
+	keys        map[string]verificationKey
+	keysFetched time.Time
+}
+
+// NewProvider uses client for every request to the issuer, which is how a
+// local mock issuer or a custom transport is plugged in.
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+
+	return &Provider{
+		cfg:    cfg,
+		client: client,
+		keys:   make(map[string]verificationKey),
+	}
+}
+
+// AuthCodeURL is where the browser is sent to sign in. The verifier is kept
+// by the caller and sent with the code, the IdP only sees its S256 hash.
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	u, err := url.Parse(d.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("error happened in url.Parse: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientId)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+type tokenResponse struct {
+	IdToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange redeems the code and returns the identity from the verified ID
+// token.
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string, nonce string) (*models.ExternalIdentity, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientId},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error happened in http.NewRequest: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientId), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	token := tokenResponse{}
+	status, err := p.do(req, &token)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || token.IdToken == "" {
+		return nil, fmt.Errorf("%w: %d %s %s", ErrExchange, status, token.Error, token.ErrorDescription)
+	}
+
+	return p.verify(ctx, d, token.IdToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, d *discovery, idToken string, nonce string) (*models.ExternalIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.key(ctx, d, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		alg := token.Method.Alg()
+		if strings.HasPrefix(alg, "HS") || (key.alg != "" && key.alg != alg) {
+			return nil, fmt.Errorf("unexpected signing method %v", alg)
+		}
+
+		return key.key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIdToken, err)
+	}
+
+	if !claims.VerifyIssuer(d.Issuer, true) {
+		return nil, fmt.Errorf("%w: issuer mismatch", ErrInvalidIdToken)
+	}
+	if !claims.VerifyAudience(p.cfg.ClientId, true) {
+		return nil, fmt.Errorf("%w: audience mismatch", ErrInvalidIdToken)
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidIdToken)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIdToken)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIdToken)
+	}
+
+	identity := &models.ExternalIdentity{
+		Issuer:  d.Issuer,
+		Subject: subject,
+		Groups:  stringList(claims[p.cfg.GroupsClaim]),
+	}
+	identity.Username, _ = claims["preferred_username"].(string)
+	identity.Email, _ = claims["email"].(string)
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		identity.AuthTime = time.Unix(int64(authTime), 0)
+	}
+
+	return identity, nil
+}
+
+func (p *Provider) getDiscovery(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(p.cfg.Issuer, "/")+discoveryPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error happened in http.NewRequest: %w", err)
+	}
+
+	d := &discovery{}
+	status, err := p.do(req, d)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery document: unexpected status %d", status)
+	}
+
+	// OpenID Connect Discovery 1.0, section 4.3
+	if d.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery document is for issuer %q, expected %q", d.Issuer, p.cfg.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JwksURI == "" {
+		return nil, errors.New("discovery document misses an endpoint")
+	}
+
+	p.discovery = d
+	return d, nil
+}
+
+// key returns the key of the issuer with the kid, fetching the JWKS again
+// when the kid is unknown, since the issuer may have rotated its keys.
+func (p *Provider) key(ctx context.Context, d *discovery, kid string) (verificationKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetched) < jwksMinRefresh {
+		return verificationKey{}, fmt.Errorf("unknown key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JwksURI, nil)
+	if err != nil {
+		return verificationKey{}, fmt.Errorf("error happened in http.NewRequest: %w", err)
+	}
+
+	set := jsonWebKeySet{}
+	status, err := p.do(req, &set)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	if status != http.StatusOK {
+		return verificationKey{}, fmt.Errorf("JWKS: unexpected status %d", status)
+	}
+
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+
+		keys[k.Kid] = verificationKey{alg: k.Alg, key: pub}
+	}
+
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return verificationKey{}, fmt.Errorf("unknown key %q", kid)
+	}
+
+	return key, nil
+}
+
+func (p *Provider) do(req *http.Request, v interface{}) (int, error) {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error happened in client.Do: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return 0, fmt.Errorf("error happened in io.ReadAll: %w", err)
+	}
+
+	if err = json.Unmarshal(body, v); err != nil && res.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("error happened in json.Unmarshal: %w", err)
+	}
+
+	return res.StatusCode, nil
+}
+
+// stringList reads a claim that is either a list of strings or one string.
+func stringList(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
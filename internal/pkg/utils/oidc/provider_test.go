@@ -0,0 +1,318 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	testClientId     = "movies"
+	testClientSecret = "secret"
+	testCode         = "code"
+	testVerifier     = "verifier"
+	testNonce        = "nonce"
+	testKid          = "key-1"
+)
+
+var testKey = mustRSAKey()
+
+func mustRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	return key
+}
+
+// mockIssuer is an OpenID Connect issuer answering discovery, the token
+// endpoint and the JWKS. The ID token it issues is made by idToken from the
+// URL of the issuer.
+type mockIssuer struct {
+	*httptest.Server
+	idToken func(issuer string) string
+}
+
+func newMockIssuer(t *testing.T, idToken func(issuer string) string) *mockIssuer {
+	t.Helper()
+
+	m := &mockIssuer{idToken: idToken}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, discovery{
+			Issuer:                m.URL,
+			AuthorizationEndpoint: m.URL + "/authorize",
+			TokenEndpoint:         m.URL + "/token",
+			JwksURI:               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		switch {
+		case id != testClientId || secret != testClientSecret:
+			writeJSON(w, http.StatusUnauthorized, tokenResponse{Error: "invalid_client"})
+		case r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("code") != testCode:
+			writeJSON(w, http.StatusBadRequest, tokenResponse{Error: "invalid_grant"})
+		case r.PostFormValue("code_verifier") != testVerifier:
+			writeJSON(w, http.StatusBadRequest, tokenResponse{Error: "invalid_grant", ErrorDescription: "PKCE verification failed"})
+		default:
+			writeJSON(w, http.StatusOK, tokenResponse{IdToken: m.idToken(m.URL)})
+		}
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, jsonWebKeySet{Keys: []jsonWebKey{{
+			Kty: "RSA",
+			Kid: testKid,
+			Alg: "RS256",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(testKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(testKey.E)).Bytes()),
+		}}})
+	})
+
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (m *mockIssuer) provider() *Provider {
+	return NewProvider(Config{
+		Issuer:       m.URL,
+		ClientId:     testClientId,
+		ClientSecret: testClientSecret,
+		RedirectURL:  "https://movies.example/api/auth/oidc/callback",
+	}, m.Client())
+}
+
+var testAuthTime = time.Unix(1700000000, 0)
+
+func validClaims(issuer string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":                issuer,
+		"aud":                testClientId,
+		"sub":                "42",
+		"exp":                time.Now().Add(time.Minute).Unix(),
+		"nonce":              testNonce,
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"groups":             []string{"admins", "staff"},
+		"auth_time":          testAuthTime.Unix(),
+	}
+}
+
+func sign(method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return signed
+}
+
+func signedWith(modify func(jwt.MapClaims)) func(string) string {
+	return func(issuer string) string {
+		claims := validClaims(issuer)
+		modify(claims)
+		return sign(jwt.SigningMethodRS256, testKid, testKey, claims)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockIssuer(t, signedWith(func(jwt.MapClaims) {}))
+
+	identity, err := m.provider().Exchange(context.Background(), testCode, testVerifier, testNonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	if identity.Issuer != m.URL || identity.Subject != "42" {
+		t.Errorf("identity = %s#%s, want %s#42", identity.Issuer, identity.Subject, m.URL)
+	}
+	if identity.Username != "alice" || identity.Email != "alice@example.com" {
+		t.Errorf("identity names = %q %q, want alice alice@example.com", identity.Username, identity.Email)
+	}
+	if !slices.Equal(identity.Groups, []string{"admins", "staff"}) {
+		t.Errorf("identity groups = %v, want [admins staff]", identity.Groups)
+	}
+	if !identity.AuthTime.Equal(testAuthTime) {
+		t.Errorf("identity auth time = %v, want %v", identity.AuthTime, testAuthTime)
+	}
+}
+
+func TestExchangeWithoutAuthTime(t *testing.T) {
+	m := newMockIssuer(t, signedWith(func(claims jwt.MapClaims) { delete(claims, "auth_time") }))
+
+	identity, err := m.provider().Exchange(context.Background(), testCode, testVerifier, testNonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if !identity.AuthTime.IsZero() {
+		t.Errorf("identity auth time = %v, want zero", identity.AuthTime)
+	}
+}
+
+func TestExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		idToken  func(issuer string) string
+		code     string
+		verifier string
+		want     error
+	}{
+		{
+			name:     "wrong code",
+			idToken:  signedWith(func(jwt.MapClaims) {}),
+			code:     "stolen",
+			verifier: testVerifier,
+			want:     ErrExchange,
+		},
+		{
+			name:     "wrong PKCE verifier",
+			idToken:  signedWith(func(jwt.MapClaims) {}),
+			code:     testCode,
+			verifier: "guessed",
+			want:     ErrExchange,
+		},
+		{
+			name:     "other nonce",
+			idToken:  signedWith(func(c jwt.MapClaims) { c["nonce"] = "replayed" }),
+			code:     testCode,
+			verifier: testVerifier,
+			want:     ErrInvalidIdToken,
+		},
+		{
+			name:     "other audience",
+			idToken:  signedWith(func(c jwt.MapClaims) { c["aud"] = "another-client" }),
+			code:     testCode,
+			verifier: testVerifier,
+			want:     ErrInvalidIdToken,
+		},
+		{
+			name:     "other issuer",
+			idToken:  signedWith(func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }),
+			code:     testCode,
+			verifier: testVerifier,
+			want:     ErrInvalidIdToken,
+		},
+		{
+			name:     "expired",
+			idToken:  signedWith(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }),
+			code:     testCode,
+			verifier: testVerifier,
+			want:     ErrInvalidIdToken,
+		},
+		{
+			name:     "no subject",
+			idToken:  signedWith(func(c jwt.MapClaims) { delete(c, "sub") }),
+			code:     testCode,
+			verifier: testVerifier,
+			want:     ErrInvalidIdToken,
+		},
+		{
+			name: "signed by another key",
+			idToken: func(issuer string) string {
+				return sign(jwt.SigningMethodRS256, testKid, mustRSAKey(), validClaims(issuer))
+			},
+			code:     testCode,
+			verifier: testVerifier,
+			want:     ErrInvalidIdToken,
+		},
+		{
+			name: "unknown kid",
+			idToken: func(issuer string) string {
+				return sign(jwt.SigningMethodRS256, "key-2", testKey, validClaims(issuer))
+			},
+			code:     testCode,
+			verifier: testVerifier,
+			want:     ErrInvalidIdToken,
+		},
+		{
+			name: "HMAC with the public key as secret",
+			idToken: func(issuer string) string {
+				return sign(jwt.SigningMethodHS256, testKid, testKey.N.Bytes(), validClaims(issuer))
+			},
+			code:     testCode,
+			verifier: testVerifier,
+			want:     ErrInvalidIdToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockIssuer(t, tt.idToken)
+
+			_, err := m.provider().Exchange(context.Background(), tt.code, tt.verifier, testNonce)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Exchange error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	m := newMockIssuer(t, signedWith(func(jwt.MapClaims) {}))
+
+	raw, err := m.provider().AuthCodeURL(context.Background(), "state", testNonce, testVerifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != m.URL+"/authorize" {
+		t.Errorf("endpoint = %s, want %s/authorize", got, m.URL)
+	}
+
+	challenge := sha256.Sum256([]byte(testVerifier))
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientId,
+		"state":                 "state",
+		"nonce":                 testNonce,
+		"scope":                 "openid profile email",
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(challenge[:]),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := u.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if u.Query().Has("code_verifier") {
+		t.Errorf("the verifier is sent to the browser")
+	}
+}
+
+func TestDiscoveryOfAnotherIssuer(t *testing.T) {
+	m := newMockIssuer(t, signedWith(func(jwt.MapClaims) {}))
+	p := NewProvider(Config{Issuer: m.URL + "/", ClientId: testClientId}, m.Client())
+
+	if _, err := p.AuthCodeURL(context.Background(), "state", testNonce, testVerifier); err == nil {
+		t.Errorf("AuthCodeURL accepted a discovery document of another issuer")
+	}
+}
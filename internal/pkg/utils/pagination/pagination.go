@@ -0,0 +1,210 @@
+package pagination
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/apperr"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = apperr.BadRequest("invalid_cursor", "the cursor is malformed or belongs to another listing")
+
+// Parse reads the limit, offset, cursor and count parameters of a listing.
+// A page is taken either by offset or after a cursor, never both.
+func Parse(query url.Values) (*models.PageRequest, error) {
+	fields := make(map[string]string)
+	page := &models.PageRequest{
+		Limit:  DefaultLimit,
+		Cursor: query.Get("cursor"),
+	}
+
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxLimit {
+			fields["limit"] = fmt.Sprintf("must be a number from 1 to %d", MaxLimit)
+		}
+		page.Limit = n
+	}
+
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			fields["offset"] = "must be a non-negative number"
+		}
+		page.Offset = n
+	}
+
+	if v := query.Get("count"); v != "" {
+		count, err := strconv.ParseBool(v)
+		if err != nil {
+			fields["count"] = "must be true or false"
+		}
+		page.Count = count
+	}
+
+	if page.Offset != 0 && page.Cursor != "" {
+		fields["offset"] = "cannot be combined with cursor"
+	}
+
+	if len(fields) != 0 {
+		err := apperr.BadRequest("invalid_query", "invalid query parameters")
+		err.Fields = fields
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Key is a column of the sort order of a listing. The last key must be
+// unique, the id, so that the order is total and cursors are stable.
+type Key struct {
+	Column string
+	Desc   bool
+}
+
+// Keys maps the sort order to the columns of a listing, appending the unique
+// id column unless the order already has it. It reports false when a field
+// has no column.
+func Keys(sort []models.SortKey, columns map[string]string, id string) ([]Key, bool) {
+	keys := make([]Key, 0, len(sort)+1)
+	hasId := false
+	for _, key := range sort {
+		column, ok := columns[key.Field]
+		if !ok {
+			return nil, false
+		}
+
+		hasId = hasId || column == id
+		keys = append(keys, Key{Column: column, Desc: key.Desc})
+	}
+
+	if !hasId {
+		keys = append(keys, Key{Column: id})
+	}
+
+	return keys, true
+}
+
+func Order(keys []Key) string {
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		terms[i] = key.Column
+		if key.Desc {
+			terms[i] += " DESC"
+		}
+	}
+
+	return strings.Join(terms, ", ")
+}
+
+// After returns the condition selecting the rows that come after the row
+// with the given key values, bound to $n, $n+1, ... in the order of keys:
+//
+//	(k1 > $n) OR (k1 = $n AND k2 > $n+1) OR ...
+//
+// It works for any mix of directions, unlike a row comparison.
+func After(keys []Key, n int) string {
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = $%d", keys[j].Column, n+j))
+		}
+
+		op := ">"
+		if key.Desc {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s $%d", key.Column, op, n+i))
+		terms[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+
+	return "(" + strings.Join(terms, " OR ") + ")"
+}
+
+type cursor struct {
+	Listing string            `json:"l"`
+	Values  []json.RawMessage `json:"v"`
+}
+
+// EncodeCursor returns the opaque cursor of the row with the given key
+// values. Listing names the listing and its sort order, a cursor is only
+// valid for the listing it was made for.
+func EncodeCursor(listing string, values ...any) string {
+	c := cursor{Listing: listing, Values: make([]json.RawMessage, len(values))}
+	for i, v := range values {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		c.Values[i] = raw
+	}
+
+	body, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+// DecodeCursor reads the key values of the cursor into dst, pointers to the
+// types of the keys.
+func DecodeCursor(s string, listing string, dst ...any) error {
+	body, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	c := cursor{}
+	if err = json.Unmarshal(body, &c); err != nil || c.Listing != listing || len(c.Values) != len(dst) {
+		return ErrInvalidCursor
+	}
+
+	for i, raw := range c.Values {
+		if err = json.Unmarshal(raw, dst[i]); err != nil {
+			return ErrInvalidCursor
+		}
+	}
+
+	return nil
+}
+
+// SetLinks sets the Link header (RFC 8288) of a listing response to the
+// first, the previous and the next page. The previous page is known only when
+// paging by offset.
+func SetLinks(w http.ResponseWriter, r *http.Request, page *models.PageRequest, next string) {
+	link := func(rel string, set map[string]string) string {
+		query := r.URL.Query()
+		query.Del("offset")
+		query.Del("cursor")
+		for k, v := range set {
+			query.Set(k, v)
+		}
+
+		u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+	}
+
+	links := []string{link("first", nil)}
+	if page.Cursor == "" && page.Offset > 0 {
+		prev := max(page.Offset-page.Limit, 0)
+		links = append(links, link("prev", map[string]string{"offset": strconv.Itoa(prev)}))
+	}
+
+	if next != "" {
+		links = append(links, link("next", map[string]string{"cursor": next}))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
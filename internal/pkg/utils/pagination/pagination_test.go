@@ -0,0 +1,176 @@
+package pagination
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/apperr"
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	release := time.Date(1979, 5, 25, 0, 0, 0, 0, time.UTC)
+	c := EncodeCursor("movies:release_date-,id", release, 8.5, "Alien", 42)
+
+	var (
+		gotRelease time.Time
+		gotRating  float64
+		gotName    string
+		gotId      int
+	)
+	if err := DecodeCursor(c, "movies:release_date-,id", &gotRelease, &gotRating, &gotName, &gotId); err != nil {
+		t.Fatalf("DecodeCursor = %v", err)
+	}
+
+	if !gotRelease.Equal(release) || gotRating != 8.5 || gotName != "Alien" || gotId != 42 {
+		t.Errorf("DecodeCursor = %v, %v, %q, %d", gotRelease, gotRating, gotName, gotId)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	valid := EncodeCursor("movies:id", 42)
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "!!!"},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte(`{"l":"movies:id","v":[42]}`))},
+		{name: "truncated", cursor: valid[:len(valid)-3]},
+		{name: "not json", cursor: raw("movies:id 42")},
+		{name: "other listing", cursor: EncodeCursor("actors:id", 42)},
+		{name: "other sort order", cursor: EncodeCursor("movies:name,id", 42)},
+		{name: "too few values", cursor: EncodeCursor("movies:id")},
+		{name: "too many values", cursor: EncodeCursor("movies:id", 42, 43)},
+		{name: "wrong type", cursor: EncodeCursor("movies:id", "42")},
+		{name: "empty", cursor: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var id int
+			if err := DecodeCursor(tt.cursor, "movies:id", &id); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   *models.PageRequest
+		fields []string
+	}{
+		{query: "", want: &models.PageRequest{Limit: DefaultLimit}},
+		{query: "limit=5&offset=10&count=true", want: &models.PageRequest{Limit: 5, Offset: 10, Count: true}},
+		{query: "limit=100&cursor=abc", want: &models.PageRequest{Limit: 100, Cursor: "abc"}},
+		{query: "limit=0", fields: []string{"limit"}},
+		{query: "limit=101", fields: []string{"limit"}},
+		{query: "limit=ten", fields: []string{"limit"}},
+		{query: "offset=-1", fields: []string{"offset"}},
+		{query: "count=maybe", fields: []string{"count"}},
+		{query: "offset=10&cursor=abc", fields: []string{"offset"}},
+		{query: "limit=0&count=maybe", fields: []string{"count", "limit"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			page, err := Parse(query)
+			if tt.fields == nil {
+				if err != nil || !reflect.DeepEqual(page, tt.want) {
+					t.Errorf("Parse = %+v, %v, want %+v", page, err, tt.want)
+				}
+				return
+			}
+
+			var appErr *apperr.Error
+			if !errors.As(err, &appErr) || !errors.Is(err, apperr.ErrBadRequest) {
+				t.Fatalf("Parse = %v, want a bad request", err)
+			}
+			for _, field := range tt.fields {
+				if appErr.Fields[field] == "" {
+					t.Errorf("no message for %s in %v", field, appErr.Fields)
+				}
+			}
+			if len(appErr.Fields) != len(tt.fields) {
+				t.Errorf("Fields = %v, want only %v", appErr.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestKeys(t *testing.T) {
+	columns := map[string]string{"id": "m.id", "name": "m.name", "rating": "m.rating"}
+
+	keys, ok := Keys([]models.SortKey{{Field: "rating", Desc: true}, {Field: "name"}}, columns, "m.id")
+	want := []Key{{Column: "m.rating", Desc: true}, {Column: "m.name"}, {Column: "m.id"}}
+	if !ok || !reflect.DeepEqual(keys, want) {
+		t.Errorf("Keys = %v, %v, want %v", keys, ok, want)
+	}
+
+	keys, ok = Keys([]models.SortKey{{Field: "id", Desc: true}}, columns, "m.id")
+	want = []Key{{Column: "m.id", Desc: true}}
+	if !ok || !reflect.DeepEqual(keys, want) {
+		t.Errorf("Keys = %v, %v, want %v", keys, ok, want)
+	}
+
+	if _, ok = Keys([]models.SortKey{{Field: "budget"}}, columns, "m.id"); ok {
+		t.Error("Keys accepted a field without a column")
+	}
+}
+
+func TestOrderAndAfter(t *testing.T) {
+	keys := []Key{{Column: "rating", Desc: true}, {Column: "name"}, {Column: "id"}}
+
+	if got, want := Order(keys), "rating DESC, name, id"; got != want {
+		t.Errorf("Order = %q, want %q", got, want)
+	}
+
+	want := "((rating < $3) OR (rating = $3 AND name > $4) OR (rating = $3 AND name = $4 AND id > $5))"
+	if got := After(keys, 3); got != want {
+		t.Errorf("After = %q, want %q", got, want)
+	}
+}
+
+func TestSetLinks(t *testing.T) {
+	tests := []struct {
+		target string
+		page   models.PageRequest
+		next   string
+		want   string
+	}{
+		{
+			target: "/api/movies?limit=10&offset=25&sort=name",
+			page:   models.PageRequest{Limit: 10, Offset: 25},
+			next:   "abc",
+			want:   `</api/movies?limit=10&sort=name>; rel="first", </api/movies?limit=10&offset=15&sort=name>; rel="prev", </api/movies?cursor=abc&limit=10&sort=name>; rel="next"`,
+		},
+		{
+			target: "/api/movies?limit=10&offset=5",
+			page:   models.PageRequest{Limit: 10, Offset: 5},
+			want:   `</api/movies?limit=10>; rel="first", </api/movies?limit=10&offset=0>; rel="prev"`,
+		},
+		{
+			target: "/api/movies?cursor=xyz",
+			page:   models.PageRequest{Limit: DefaultLimit, Cursor: "xyz"},
+			want:   `</api/movies>; rel="first"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			SetLinks(w, httptest.NewRequest("GET", tt.target, nil), &tt.page, tt.next)
+			if got := w.Header().Get("Link"); got != tt.want {
+				t.Errorf("Link = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package pgtest
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const (
+	seedMovies = "INSERT INTO movie (name, description, release_date, rating) " +
+		"SELECT 'movie ' || i, 'the description of movie ' || i, date '1950-01-01' + i, i % 11 FROM generate_series(1, $1) AS i;"
+	seedActors = "INSERT INTO actor (name, surname, gender, birth_date) " +
+		"SELECT 'name ' || i, 'surname ' || i, CASE WHEN i % 2 = 0 THEN 'F' ELSE 'M' END, date '1930-01-01' + i FROM generate_series(1, $1) AS i;"
+	seedCasts = "INSERT INTO movie_actor (movie_id, actor_id) " +
+		"SELECT m.id, (m.id * 7 + j) % $1 + 1 FROM movie AS m, generate_series(0, $2 - 1) AS j ON CONFLICT DO NOTHING;"
+)
+
+// Open connects to the database of TEST_DATABASE_URL, in a schema of its own
+// made by db/init.sql and dropped when the test ends. The test is skipped
+// when the variable is not set.
+func Open(tb testing.TB) *pgxpool.Pool {
+	tb.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		tb.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		tb.Fatalf("error happened in pgx.Connect: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err = conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		tb.Fatalf("error happened in conn.Exec: %v", err)
+	}
+
+	tb.Cleanup(func() {
+		conn, err := pgx.Connect(ctx, url)
+		if err != nil {
+			tb.Errorf("error happened in pgx.Connect: %v", err)
+			return
+		}
+		defer conn.Close(ctx)
+
+		if _, err = conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			tb.Errorf("error happened in conn.Exec: %v", err)
+		}
+	})
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		tb.Fatalf("error happened in pgxpool.ParseConfig: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+
+	db, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		tb.Fatalf("error happened in pgxpool.NewWithConfig: %v", err)
+	}
+	tb.Cleanup(db.Close)
+
+	_, file, _, _ := runtime.Caller(0)
+	script, err := os.ReadFile(filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "db", "init.sql"))
+	if err != nil {
+		tb.Fatalf("error happened in os.ReadFile: %v", err)
+	}
+
+	// without arguments the script runs as one multi-statement query
+	if _, err = db.Exec(ctx, string(script)); err != nil {
+		tb.Fatalf("error happened in db.Exec: %v", err)
+	}
+
+	return db
+}
+
+// Seed adds movies with ids from 1 to movies, actors with ids from 1 to
+// actors and gives every movie a cast of cast actors.
+func Seed(tb testing.TB, db *pgxpool.Pool, movies int, actors int, cast int) {
+	tb.Helper()
+
+	ctx := context.Background()
+	for _, step := range []struct {
+		query string
+		args  []any
+	}{
+		{query: seedMovies, args: []any{movies}},
+		{query: seedActors, args: []any{actors}},
+		{query: seedCasts, args: []any{actors, cast}},
+	} {
+		if _, err := db.Exec(ctx, step.query, step.args...); err != nil {
+			tb.Fatalf("error happened in db.Exec: %v", err)
+		}
+	}
+}
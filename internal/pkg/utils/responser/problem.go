@@ -0,0 +1,98 @@
+package responser
+
+import (
+	"MovieService/internal/pkg/apperr"
+	"MovieService/internal/pkg/utils/logger"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is the body of every error response, RFC 7807 problem details
+// extended with a machine-readable code and a message per invalid field.
+type Problem struct {
+	Type   string            `json:"type"`
+	Title  string            `json:"title"`
+	Status int               `json:"status"`
+	Detail string            `json:"detail,omitempty"`
+	Code   string            `json:"code"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+var statuses = map[error]int{
+	apperr.ErrBadRequest:      http.StatusBadRequest,
+	apperr.ErrUnauthorized:    http.StatusUnauthorized,
+	apperr.ErrForbidden:       http.StatusForbidden,
+	apperr.ErrNotFound:        http.StatusNotFound,
+	apperr.ErrConflict:        http.StatusConflict,
+	apperr.ErrPrecondition:    http.StatusPreconditionFailed,
+	apperr.ErrValidation:      http.StatusUnprocessableEntity,
+	apperr.ErrTooManyRequests: http.StatusTooManyRequests,
+	apperr.ErrMediaType:       http.StatusUnsupportedMediaType,
+}
+
+// Error answers with the problem matching the domain error. Anything else is
+// logged and answered with a bare 500, since its message may expose internals.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		if status, ok := statuses[appErr.Kind]; ok {
+			if appErr.Err != nil {
+				logger.FromContext(r.Context()).Debug("request failed", "status", status, "error", err)
+			}
+
+			p := NewProblem(status, appErr.Code, appErr.Message)
+			p.Fields = appErr.Fields
+			WriteProblem(w, p)
+			return
+		}
+	}
+
+	logger.FromContext(r.Context()).Error("request failed", "error", err)
+	Fail(w, http.StatusInternalServerError, "internal server error")
+}
+
+// Fail answers with a problem of the status and the code derived from it.
+func Fail(w http.ResponseWriter, status int, detail string) {
+	WriteProblem(w, NewProblem(status, "", detail))
+}
+
+// FailFields is Fail with a message per invalid field.
+func FailFields(w http.ResponseWriter, status int, detail string, fields map[string]string) {
+	p := NewProblem(status, "", detail)
+	p.Fields = fields
+	WriteProblem(w, p)
+}
+
+// NewProblem fills in the title and, when code is empty, derives the code
+// from the status, e.g. "not_found" for 404.
+func NewProblem(status int, code string, detail string) Problem {
+	if code == "" {
+		code = strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
+
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		w.WriteHeader(p.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,239 @@
+package router
+
+import (
+	resp "MovieService/internal/pkg/utils/responser"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Router dispatches requests by method and path pattern:
+//
+//	rt.HandleFunc("GET /api/movies/{id:int}", getMovie)
+//
+// A path segment is either a literal or a {name} wildcard, optionally typed
+// as {name:int}. Wildcards are read with http.Request.PathValue, or Int for
+// typed ones. When several patterns match a path the most specific one wins:
+// a literal beats an int wildcard, which beats a plain one. Trailing slashes
+// are ignored, a path that matches with another method gets 405 with an
+// Allow header and GET routes answer HEAD as well.
+type Router struct {
+	routes []*route
+}
+
+type segment struct {
+	literal string
+	param   string
+	isInt   bool
+}
+
+type route struct {
+	path     string
+	segments []segment
+	handlers map[string]http.Handler
+}
+
+type matchKey struct{}
+
+// Match is filled in with the pattern that served the request, for middleware
+// running before the router such as the access log.
+type Match struct {
+	Pattern string
+}
+
+// WithMatch returns a context the router records the matched pattern in.
+func WithMatch(ctx context.Context) (context.Context, *Match) {
+	m := &Match{}
+	return context.WithValue(ctx, matchKey{}, m), m
+}
+
+func New() *Router {
+	return &Router{}
+}
+
+func (rt *Router) HandleFunc(pattern string, h http.HandlerFunc) {
+	rt.Handle(pattern, h)
+}
+
+// Handle registers the handler for a "METHOD /path" pattern. It panics on a
+// malformed or duplicate pattern, like http.ServeMux.
+func (rt *Router) Handle(pattern string, h http.Handler) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok || method == "" || !strings.HasPrefix(path, "/") {
+		panic(fmt.Sprintf("router: invalid pattern %q", pattern))
+	}
+
+	path = normalize(path)
+	for _, r := range rt.routes {
+		if r.path != path {
+			continue
+		}
+
+		if _, ok := r.handlers[method]; ok {
+			panic(fmt.Sprintf("router: pattern %q registered twice", pattern))
+		}
+		r.handlers[method] = h
+		return
+	}
+
+	r := &route{
+		path:     path,
+		segments: parse(pattern, path),
+		handlers: map[string]http.Handler{method: h},
+	}
+	rt.routes = append(rt.routes, r)
+	sort.SliceStable(rt.routes, func(i, j int) bool {
+		return moreSpecific(rt.routes[i], rt.routes[j])
+	})
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	parts := strings.Split(normalize(r.URL.EscapedPath()), "/")[1:]
+
+	var allowed []string
+	for _, route := range rt.routes {
+		values, ok := route.match(parts)
+		if !ok {
+			continue
+		}
+
+		h, ok := route.handlers[r.Method]
+		if !ok && r.Method == http.MethodHead {
+			h, ok = route.handlers[http.MethodGet]
+		}
+		if !ok {
+			allowed = append(allowed, route.methods()...)
+			continue
+		}
+
+		if m, ok := r.Context().Value(matchKey{}).(*Match); ok {
+			m.Pattern = r.Method + " " + route.path
+		}
+		for name, value := range values {
+			r.SetPathValue(name, value)
+		}
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	if allowed != nil {
+		slices.Sort(allowed)
+		w.Header().Set("Allow", strings.Join(slices.Compact(allowed), ", "))
+		resp.Fail(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	resp.Fail(w, http.StatusNotFound, "not found")
+}
+
+// Int returns a {name:int} wildcard of the matched pattern.
+func Int(r *http.Request, name string) int {
+	v, _ := strconv.Atoi(r.PathValue(name))
+	return v
+}
+
+func (r *route) match(parts []string) (map[string]string, bool) {
+	if len(parts) != len(r.segments) {
+		return nil, false
+	}
+
+	values := make(map[string]string)
+	for i, seg := range r.segments {
+		part, err := url.PathUnescape(parts[i])
+		if err != nil {
+			return nil, false
+		}
+
+		switch {
+		case seg.param == "":
+			if part != seg.literal {
+				return nil, false
+			}
+		case part == "":
+			return nil, false
+		case seg.isInt:
+			if _, err = strconv.Atoi(part); err != nil {
+				return nil, false
+			}
+			values[seg.param] = part
+		default:
+			values[seg.param] = part
+		}
+	}
+
+	return values, true
+}
+
+func (r *route) methods() []string {
+	methods := make([]string, 0, len(r.handlers)+1)
+	for method := range r.handlers {
+		methods = append(methods, method)
+	}
+
+	if _, ok := r.handlers[http.MethodGet]; ok {
+		methods = append(methods, http.MethodHead)
+	}
+
+	return methods
+}
+
+func parse(pattern string, path string) []segment {
+	parts := strings.Split(path, "/")[1:]
+	segments := make([]segment, 0, len(parts))
+	for _, part := range parts {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			segments = append(segments, segment{literal: part})
+			continue
+		}
+
+		name, typ, _ := strings.Cut(part[1:len(part)-1], ":")
+		if name == "" || (typ != "" && typ != "int") {
+			panic(fmt.Sprintf("router: invalid wildcard %q in pattern %q", part, pattern))
+		}
+
+		segments = append(segments, segment{param: name, isInt: typ == "int"})
+	}
+
+	return segments
+}
+
+// moreSpecific orders routes so that the first match is the most specific.
+func moreSpecific(a *route, b *route) bool {
+	if len(a.segments) != len(b.segments) {
+		return len(a.segments) < len(b.segments)
+	}
+
+	for i := range a.segments {
+		if ra, rb := a.segments[i].rank(), b.segments[i].rank(); ra != rb {
+			return ra < rb
+		}
+	}
+
+	return false
+}
+
+func (s segment) rank() int {
+	switch {
+	case s.param == "":
+		return 0
+	case s.isInt:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// normalize drops trailing slashes, "/api/movies/" is "/api/movies".
+func normalize(path string) string {
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+
+	return "/"
+}
@@ -0,0 +1,128 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// handler answers with the name of the route and the wildcards it read.
+func handler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Route", name)
+		w.Header().Set("X-Id", r.PathValue("id"))
+		w.Header().Set("X-Name", r.PathValue("name"))
+	}
+}
+
+func newTestRouter() *Router {
+	rt := New()
+	rt.HandleFunc("GET /api/movies", handler("list"))
+	rt.HandleFunc("POST /api/movies", handler("create"))
+	rt.HandleFunc("GET /api/movies/search", handler("search"))
+	rt.HandleFunc("GET /api/movies/{id:int}", handler("get"))
+	rt.HandleFunc("PUT /api/movies/{id:int}", handler("update"))
+	rt.HandleFunc("GET /api/movies/{name}", handler("by-name"))
+	rt.HandleFunc("DELETE /api/movies/{id:int}/actors/{actor:int}", handler("remove-actor"))
+	return rt
+}
+
+func TestRouting(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		status int
+		route  string
+		id     string
+		name   string
+		allow  string
+	}{
+		{method: "GET", path: "/api/movies", status: 200, route: "list"},
+		{method: "GET", path: "/api/movies/", status: 200, route: "list"},
+		{method: "POST", path: "/api/movies", status: 200, route: "create"},
+		{method: "HEAD", path: "/api/movies", status: 200, route: "list"},
+		{method: "GET", path: "/api/movies/search", status: 200, route: "search"},
+		{method: "GET", path: "/api/movies/42", status: 200, route: "get", id: "42"},
+		{method: "GET", path: "/api/movies/-3", status: 200, route: "get", id: "-3"},
+		{method: "PUT", path: "/api/movies/42", status: 200, route: "update", id: "42"},
+		{method: "GET", path: "/api/movies/matrix", status: 200, route: "by-name", name: "matrix"},
+		{method: "GET", path: "/api/movies/the%20matrix", status: 200, route: "by-name", name: "the matrix"},
+		{method: "DELETE", path: "/api/movies/1/actors/2", status: 200, route: "remove-actor", id: "1"},
+		{method: "DELETE", path: "/api/movies/1/actors/two", status: 404},
+		{method: "GET", path: "/api/movies/1/actors", status: 404},
+		{method: "GET", path: "/api/actors", status: 404},
+		{method: "DELETE", path: "/api/movies", status: 405, allow: "GET, HEAD, POST"},
+		{method: "PATCH", path: "/api/movies/42", status: 405, allow: "GET, HEAD, PUT"},
+		{method: "PUT", path: "/api/movies/matrix", status: 405, allow: "GET, HEAD"},
+	}
+
+	rt := newTestRouter()
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("X-Route"); got != tt.route {
+				t.Errorf("route = %q, want %q", got, tt.route)
+			}
+			if got := w.Header().Get("X-Id"); got != tt.id {
+				t.Errorf("id = %q, want %q", got, tt.id)
+			}
+			if got := w.Header().Get("X-Name"); got != tt.name {
+				t.Errorf("name = %q, want %q", got, tt.name)
+			}
+			if got := w.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+}
+
+func TestInt(t *testing.T) {
+	rt := New()
+	var id int
+	rt.HandleFunc("GET /api/movies/{id:int}", func(w http.ResponseWriter, r *http.Request) {
+		id = Int(r, "id")
+	})
+
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/movies/17", nil))
+	if id != 17 {
+		t.Errorf("Int = %d, want 17", id)
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	rt := newTestRouter()
+	r := httptest.NewRequest("GET", "/api/movies/42/", nil)
+	ctx, m := WithMatch(r.Context())
+
+	rt.ServeHTTP(httptest.NewRecorder(), r.WithContext(ctx))
+	if m.Pattern != "GET /api/movies/{id:int}" {
+		t.Errorf("Pattern = %q, want GET /api/movies/{id:int}", m.Pattern)
+	}
+}
+
+func TestHandlePanics(t *testing.T) {
+	for _, pattern := range []string{
+		"/api/movies",
+		"GET api/movies",
+		"GET /api/movies/{id:uuid}",
+		"GET /api/movies/{}",
+		"GET /api/movies",
+	} {
+		t.Run(pattern, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Handle(%q) did not panic", pattern)
+				}
+			}()
+
+			rt := New()
+			rt.HandleFunc("GET /api/movies", handler("list"))
+			rt.HandleFunc(pattern, handler("other"))
+		})
+	}
+}
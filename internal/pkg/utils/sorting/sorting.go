@@ -0,0 +1,55 @@
+package sorting
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/apperr"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Parse reads a sort order such as "-rating,name": fields separated by
+// commas, each descending when prefixed with a minus. Only the allowed fields
+// are accepted, each of them once.
+func Parse(spec string, allowed ...string) ([]models.SortKey, error) {
+	invalid := func(format string, args ...any) error {
+		err := apperr.BadRequest("invalid_sort", "invalid sort order")
+		err.Fields = map[string]string{"sort": fmt.Sprintf(format, args...)}
+		return err
+	}
+
+	keys := make([]models.SortKey, 0)
+	for _, term := range strings.Split(spec, ",") {
+		key := models.SortKey{Field: strings.TrimSpace(term)}
+		if strings.HasPrefix(key.Field, "-") {
+			key.Field, key.Desc = key.Field[1:], true
+		}
+
+		if key.Field == "" {
+			return nil, invalid("empty field")
+		}
+		if !slices.Contains(allowed, key.Field) {
+			return nil, invalid("unknown field %q, expected %s", key.Field, strings.Join(allowed, ", "))
+		}
+		if slices.ContainsFunc(keys, func(k models.SortKey) bool { return k.Field == key.Field }) {
+			return nil, invalid("field %q is given twice", key.Field)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// String returns the canonical form of the sort order, as Parse reads it.
+func String(keys []models.SortKey) string {
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		terms[i] = key.Field
+		if key.Desc {
+			terms[i] = "-" + key.Field
+		}
+	}
+
+	return strings.Join(terms, ",")
+}
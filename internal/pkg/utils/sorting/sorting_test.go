@@ -0,0 +1,63 @@
+package sorting
+
+import (
+	"MovieService/internal/models"
+	"MovieService/internal/pkg/apperr"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	allowed := []string{"name", "rating", "release_date", "id"}
+	tests := []struct {
+		spec string
+		want []models.SortKey
+		msg  string
+	}{
+		{spec: "name", want: []models.SortKey{{Field: "name"}}},
+		{spec: "-rating,name", want: []models.SortKey{{Field: "rating", Desc: true}, {Field: "name"}}},
+		{spec: " -release_date , id ", want: []models.SortKey{{Field: "release_date", Desc: true}, {Field: "id"}}},
+		{spec: "", msg: "empty field"},
+		{spec: "name,", msg: "empty field"},
+		{spec: "-", msg: "empty field"},
+		{spec: "budget", msg: `unknown field "budget", expected name, rating, release_date, id`},
+		{spec: "name;drop table movie", msg: `unknown field "name;drop table movie", expected name, rating, release_date, id`},
+		{spec: "Name", msg: `unknown field "Name", expected name, rating, release_date, id`},
+		{spec: "--name", msg: `unknown field "-name", expected name, rating, release_date, id`},
+		{spec: "+name", msg: `unknown field "+name", expected name, rating, release_date, id`},
+		{spec: "name,-name", msg: `field "name" is given twice`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			keys, err := Parse(tt.spec, allowed...)
+			if tt.msg == "" {
+				if err != nil || !reflect.DeepEqual(keys, tt.want) {
+					t.Errorf("Parse = %v, %v, want %v", keys, err, tt.want)
+				}
+				return
+			}
+
+			var appErr *apperr.Error
+			if !errors.As(err, &appErr) || !errors.Is(err, apperr.ErrBadRequest) {
+				t.Fatalf("Parse = %v, want a bad request", err)
+			}
+			if got := appErr.Fields["sort"]; got != tt.msg {
+				t.Errorf("message = %s, want %s", got, tt.msg)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, spec := range []string{"name", "-rating,name", "-release_date,-id"} {
+		keys, err := Parse(spec, "name", "rating", "release_date", "id")
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", spec, err)
+		}
+		if got := String(keys); got != spec {
+			t.Errorf("String = %q, want %q", got, spec)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the test vectors of RFC 6238, appendix B
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+// The RFC lists 8-digit codes; the 6-digit ones are their last six digits.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestGenerateRFC6238(t *testing.T) {
+	for _, v := range rfcVectors {
+		got, err := Generate(rfcSecret, time.Unix(v.unix, 0))
+		if err != nil || got != v.code {
+			t.Errorf("code at %d = %s, %v; want %s", v.unix, got, err, v.code)
+		}
+	}
+
+	if _, err := Generate("not base32!", time.Now()); err == nil {
+		t.Error("Generate accepted an invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, v := range rfcVectors {
+		step, ok := Validate(rfcSecret, v.code, time.Unix(v.unix, 0))
+		if !ok || step != v.unix/Period {
+			t.Errorf("Validate(%s at %d) = %d, %v; want step %d", v.code, v.unix, step, ok, v.unix/Period)
+		}
+	}
+
+	at := time.Unix(1111111111, 0)
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		now    time.Time
+		ok     bool
+	}{
+		{"previous step", rfcSecret, "050471", at.Add(Period * time.Second), true},
+		{"next step", rfcSecret, "050471", at.Add(-Period * time.Second), true},
+		{"two steps later", rfcSecret, "050471", at.Add(2 * Period * time.Second), false},
+		{"lower case secret", strings.ToLower(rfcSecret), "050471", at, true},
+		{"wrong code", rfcSecret, "050472", at, false},
+		{"8 digits", rfcSecret, "14050471", at, false},
+		{"empty code", rfcSecret, "", at, false},
+		{"invalid secret", "not base32!", "050471", at, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(tt.secret, tt.code, tt.now); ok != tt.ok {
+				t.Errorf("Validate = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != secretSize {
+		t.Errorf("secret %q decodes to %d bytes, %v; want %d", secret, len(key), err, secretSize)
+	}
+
+	other, _ := GenerateSecret()
+	if other == secret {
+		t.Errorf("two secrets are equal")
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Movie Service", "alice", rfcSecret))
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Movie Service:alice" {
+		t.Errorf("URI = %s, want otpauth://totp/Movie Service:alice", u)
+	}
+
+	want := map[string]string{"secret": rfcSecret, "issuer": "Movie Service", "algorithm": "SHA1", "digits": "6", "period": "30"}
+	for name, value := range want {
+		if got := u.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package tsquery
+
+import (
+	"html"
+	"strings"
+)
+
+// Selectors are the ts_headline options marking the matched words with
+// control characters rather than tags, Highlight turns them into tags once the
+// text is escaped.
+const Selectors = "StartSel=\"\x01\", StopSel=\"\x02\""
+
+var highlighter = strings.NewReplacer("\x01", "<b>", "\x02", "</b>")
+
+// Highlight returns a headline made with Selectors as HTML, the text escaped
+// and the matched words wrapped in <b> tags. ts_headline copies the text as
+// stored, markup included, so that its output is not safe to render as is.
+func Highlight(headline string) string {
+	return highlighter.Replace(html.EscapeString(headline))
+}
@@ -0,0 +1,119 @@
+package tsquery
+
+import (
+	"MovieService/internal/pkg/apperr"
+	"strings"
+	"unicode"
+)
+
+var ErrEmpty = apperr.BadRequest("invalid_search_query", "the search query has no words to look for")
+
+// Parse translates a search query into the syntax of to_tsquery. Words must
+// all match, "quoted words" match as a phrase, a word ending with * matches as
+// a prefix, a leading minus excludes a word or a phrase, and OR between two
+// terms lets either match:
+//
+//	"star wars" -clone OR empire*  =>  (star <-> wars) & (!clone | empire:*)
+//
+// Only letters and digits are kept from the words, so the result is always
+// a valid tsquery whatever the input.
+func Parse(q string) (string, error) {
+	groups := make([][]string, 0)
+	or := false
+	rs := []rune(q)
+	for i := 0; i < len(rs); {
+		if unicode.IsSpace(rs[i]) {
+			i++
+			continue
+		}
+
+		negate := false
+		if rs[i] == '-' {
+			negate = true
+			i++
+		}
+
+		end := i
+		var term string
+		if end < len(rs) && rs[end] == '"' {
+			end++
+			for end < len(rs) && rs[end] != '"' {
+				end++
+			}
+			term = phrase(rs[i+1 : end])
+			end++
+		} else {
+			for end < len(rs) && !unicode.IsSpace(rs[end]) && rs[end] != '"' {
+				end++
+			}
+			if !negate && string(rs[i:end]) == "OR" && len(groups) != 0 {
+				or = true
+				i = end
+				continue
+			}
+			term = phrase(rs[i:end])
+		}
+		i = end
+
+		if term == "" {
+			continue
+		}
+		if negate {
+			term = "!" + term
+		}
+
+		if or {
+			groups[len(groups)-1] = append(groups[len(groups)-1], term)
+			or = false
+		} else {
+			groups = append(groups, []string{term})
+		}
+	}
+
+	if len(groups) == 0 {
+		return "", ErrEmpty
+	}
+
+	terms := make([]string, len(groups))
+	for i, group := range groups {
+		terms[i] = strings.Join(group, " | ")
+		if len(groups) > 1 && len(group) > 1 {
+			terms[i] = "(" + terms[i] + ")"
+		}
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+// phrase returns the words of s following each other, empty when s has none.
+// A word directly followed by * is a prefix.
+func phrase(s []rune) string {
+	words := make([]string, 0)
+	for i := 0; i < len(s); {
+		start := i
+		for i < len(s) && isWordRune(s[i]) {
+			i++
+		}
+
+		if i == start {
+			i++
+			continue
+		}
+
+		word := strings.ToLower(string(s[start:i]))
+		if i < len(s) && s[i] == '*' {
+			word += ":*"
+		}
+		words = append(words, word)
+	}
+
+	if len(words) > 1 {
+		return "(" + strings.Join(words, " <-> ") + ")"
+	}
+
+	return strings.Join(words, "")
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
@@ -0,0 +1,81 @@
+package tsquery
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{q: "star", want: "star"},
+		{q: "Star Wars", want: "star & wars"},
+		{q: `"star wars" -clone OR empire*`, want: "(star <-> wars) & (!clone | empire:*)"},
+		{q: "a OR b OR c d", want: "(a | b | c) & d"},
+		{q: "a OR b", want: "a | b"},
+		{q: "-clone", want: "!clone"},
+		{q: `-"attack of the clones"`, want: "!(attack <-> of <-> the <-> clones)"},
+		{q: `"star* wars"`, want: "(star:* <-> wars)"},
+		{q: "Звёздные войны", want: "звёздные & войны"},
+		{q: "  star \t wars  ", want: "star & wars"},
+
+		// OR is an operator only in capitals and between two terms
+		{q: "OR", want: "or"},
+		{q: "OR star", want: "or & star"},
+		{q: "star OR", want: "star"},
+		{q: "star OR OR wars", want: "star | wars"},
+		{q: "star or wars", want: "star & or & wars"},
+		{q: "-OR", want: "!or"},
+
+		// quoting
+		{q: `"unclosed phrase`, want: "(unclosed <-> phrase)"},
+		{q: `star"wars"`, want: "star & wars"},
+		{q: `""star`, want: "star"},
+
+		// the characters of the tsquery syntax are dropped
+		{q: "star:* & !wars | (empire)", want: "star & wars & empire"},
+		{q: "it's", want: "(it <-> s)"},
+		{q: "a(b)c", want: "(a <-> b <-> c)"},
+		{q: `star\ wars`, want: "star & wars"},
+		{q: "- star", want: "star"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			got, err := Parse(tt.q)
+			if err != nil || got != tt.want {
+				t.Errorf("Parse = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	for _, q := range []string{"", "  \t ", `""`, `" "`, "-", `-""`, "!:*&|()", "*"} {
+		t.Run(q, func(t *testing.T) {
+			if got, err := Parse(q); !errors.Is(err, ErrEmpty) {
+				t.Errorf("Parse = %q, %v, want ErrEmpty", got, err)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{headline: "A \x01long\x02 time ago", want: "A <b>long</b> time ago"},
+		{headline: "<script>alert(\x01star\x02)</script>", want: "&lt;script&gt;alert(<b>star</b>)&lt;/script&gt;"},
+		{headline: `Tom & "Jerry" <b>`, want: "Tom &amp; &#34;Jerry&#34; &lt;b&gt;"},
+		{headline: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := Highlight(tt.headline); got != tt.want {
+			t.Errorf("Highlight(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package validator
+
+import (
+	"MovieService/internal/pkg/apperr"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Rules are declared in the validate tag of a struct field, separated by
+// commas:
+//
+//	required   the field is set; strings must not be blank
+//	min=N      strings have at least N characters, numbers are at least N
+//	max=N      strings have at most N characters, numbers are at most N
+//	oneof=A B  the value is one of the space separated options
+//
+// Apart from required the rules are skipped for empty strings, so optional
+// fields are checked only when given.
+const tagName = "validate"
+
+type rule struct {
+	name    string
+	n       int64
+	options []string
+}
+
+type field struct {
+	index []int
+	name  string
+	rules []rule
+}
+
+// fields caches the parsed rules per struct type.
+var fields sync.Map
+
+// Struct checks every field of the struct against its rules and returns an
+// apperr validation error with a message per invalid field, keyed by its JSON
+// name.
+func Struct(v any) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: %T is not a struct", v))
+	}
+
+	invalid := make(map[string]string)
+	for _, f := range fieldsOf(rv.Type()) {
+		if msg := check(rv.FieldByIndex(f.index), f.rules); msg != "" {
+			invalid[f.name] = msg
+		}
+	}
+
+	if len(invalid) != 0 {
+		return apperr.Validation(invalid)
+	}
+
+	return nil
+}
+
+func check(v reflect.Value, rules []rule) string {
+	for _, r := range rules {
+		if r.name != "required" && v.Kind() == reflect.String && v.Len() == 0 {
+			continue
+		}
+
+		if msg := apply(v, r); msg != "" {
+			return msg
+		}
+	}
+
+	return ""
+}
+
+func apply(v reflect.Value, r rule) string {
+	switch r.name {
+	case "required":
+		if v.IsZero() || v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "" {
+			return "is required"
+		}
+	case "min":
+		if n, unit := size(v); n < r.n {
+			return fmt.Sprintf("must be at least %d%s", r.n, unit)
+		}
+	case "max":
+		if n, unit := size(v); n > r.n {
+			return fmt.Sprintf("must be at most %d%s", r.n, unit)
+		}
+	case "oneof":
+		value := fmt.Sprint(v.Interface())
+		for _, option := range r.options {
+			if value == option {
+				return ""
+			}
+		}
+
+		return "must be one of " + strings.Join(r.options, ", ")
+	}
+
+	return ""
+}
+
+// size is the length of a string in characters, the value of a number or the
+// number of items of a slice or a map.
+func size(v reflect.Value) (int64, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return int64(utf8.RuneCountInString(v.String())), " characters long"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), ""
+	case reflect.Slice, reflect.Map:
+		return int64(v.Len()), " items"
+	}
+
+	panic(fmt.Sprintf("validator: min and max do not apply to %s", v.Type()))
+}
+
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fields.Load(t); ok {
+		return cached.([]field)
+	}
+
+	parsed := make([]field, 0)
+	for _, sf := range reflect.VisibleFields(t) {
+		tag, ok := sf.Tag.Lookup(tagName)
+		if !ok || !sf.IsExported() {
+			continue
+		}
+
+		parsed = append(parsed, field{
+			index: sf.Index,
+			name:  jsonName(sf),
+			rules: parseRules(t, sf.Name, tag),
+		})
+	}
+
+	fields.Store(t, parsed)
+	return parsed
+}
+
+// parseRules panics on a malformed tag, that is a bug rather than bad input.
+func parseRules(t reflect.Type, name string, tag string) []rule {
+	rules := make([]rule, 0)
+	for _, part := range strings.Split(tag, ",") {
+		ruleName, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		r := rule{name: ruleName}
+		switch ruleName {
+		case "required":
+		case "min", "max":
+			n, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				panic(fmt.Sprintf("validator: %s.%s: bad %s argument %q", t, name, ruleName, arg))
+			}
+			r.n = n
+		case "oneof":
+			r.options = strings.Fields(arg)
+		default:
+			panic(fmt.Sprintf("validator: %s.%s: unknown rule %q", t, name, ruleName))
+		}
+
+		rules = append(rules, r)
+	}
+
+	return rules
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+
+	return name
+}
@@ -0,0 +1,152 @@
+package validator
+
+import (
+	"MovieService/internal/pkg/apperr"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type movieInput struct {
+	Name        string   `json:"name" validate:"required,max=10"`
+	Description string   `json:"description,omitempty" validate:"min=3,max=5"`
+	Rating      float64  `json:"rating" validate:"required"`
+	Year        int      `json:"year" validate:"min=1888,max=2100"`
+	Genre       string   `json:"genre" validate:"oneof=drama comedy"`
+	Actors      []int    `json:"actors" validate:"max=2"`
+	Internal    string   `json:"-" validate:"required"`
+	Tags        []string `json:"tags"`
+}
+
+func valid() movieInput {
+	return movieInput{
+		Name:     "Alien",
+		Rating:   8.5,
+		Year:     1979,
+		Internal: "x",
+	}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(m *movieInput)
+		fields map[string]string
+	}{
+		{name: "valid", modify: func(m *movieInput) {}},
+		{
+			name:   "missing name",
+			modify: func(m *movieInput) { m.Name = "" },
+			fields: map[string]string{"name": "is required"},
+		},
+		{
+			name:   "blank name",
+			modify: func(m *movieInput) { m.Name = "  \t" },
+			fields: map[string]string{"name": "is required"},
+		},
+		{
+			name:   "name too long",
+			modify: func(m *movieInput) { m.Name = "Interstellar" },
+			fields: map[string]string{"name": "must be at most 10 characters long"},
+		},
+		{
+			name:   "length counts characters, not bytes",
+			modify: func(m *movieInput) { m.Name = "Сталкер Ёж" },
+		},
+		{
+			name:   "optional string skipped when empty",
+			modify: func(m *movieInput) { m.Description = "" },
+		},
+		{
+			name:   "description too short",
+			modify: func(m *movieInput) { m.Description = "ok" },
+			fields: map[string]string{"description": "must be at least 3 characters long"},
+		},
+		{
+			name:   "missing number",
+			modify: func(m *movieInput) { m.Rating = 0 },
+			fields: map[string]string{"rating": "is required"},
+		},
+		{
+			name:   "number below min",
+			modify: func(m *movieInput) { m.Year = 1800 },
+			fields: map[string]string{"year": "must be at least 1888"},
+		},
+		{
+			name:   "number above max",
+			modify: func(m *movieInput) { m.Year = 2200 },
+			fields: map[string]string{"year": "must be at most 2100"},
+		},
+		{
+			name:   "oneof",
+			modify: func(m *movieInput) { m.Genre = "horror" },
+			fields: map[string]string{"genre": "must be one of drama, comedy"},
+		},
+		{
+			name:   "too many items",
+			modify: func(m *movieInput) { m.Actors = []int{1, 2, 3} },
+			fields: map[string]string{"actors": "must be at most 2 items"},
+		},
+		{
+			name:   "field without json name",
+			modify: func(m *movieInput) { m.Internal = "" },
+			fields: map[string]string{"Internal": "is required"},
+		},
+		{
+			name: "every invalid field",
+			modify: func(m *movieInput) {
+				m.Name = ""
+				m.Year = 1
+				m.Genre = "horror"
+			},
+			fields: map[string]string{
+				"name":  "is required",
+				"year":  "must be at least 1888",
+				"genre": "must be one of drama, comedy",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := valid()
+			tt.modify(&m)
+
+			err := Struct(&m)
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatalf("Struct = %v, want nil", err)
+				}
+				return
+			}
+
+			var appErr *apperr.Error
+			if !errors.As(err, &appErr) || !errors.Is(err, apperr.ErrValidation) {
+				t.Fatalf("Struct = %v, want a validation error", err)
+			}
+			if !reflect.DeepEqual(appErr.Fields, tt.fields) {
+				t.Errorf("Fields = %v, want %v", appErr.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestStructPanics(t *testing.T) {
+	type unknownRule struct {
+		Name string `validate:"email"`
+	}
+	type badArgument struct {
+		Name string `validate:"max=ten"`
+	}
+
+	for _, v := range []any{1, unknownRule{}, badArgument{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Struct(%T) did not panic", v)
+				}
+			}()
+			_ = Struct(v)
+		}()
+	}
+}